@@ -0,0 +1,81 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// An archiveYear lists the articles of a year by month.
+type archiveYear struct {
+	Year   int
+	Path   string // path of the year page, including base
+	Months []*archiveMonth
+}
+
+// An archiveMonth lists the articles of a month.
+type archiveMonth struct {
+	Year  int
+	Month time.Month
+	Path  string // path of the month page, including base
+	Docs  []*Doc
+}
+
+// archiveData is the data of an archive page: all the articles, or those of
+// Year, or of Month of Year.
+type archiveData struct {
+	Year  int
+	Month time.Month
+	Years []*archiveYear
+}
+
+// newArchive groups docs, sorted by time, by year and month.
+func newArchive(basePath string, docs []*Doc) []*archiveYear {
+	var years []*archiveYear
+	var y *archiveYear
+	var m *archiveMonth
+	for _, d := range docs {
+		year, month, _ := d.Time.Date()
+		if y == nil || y.Year != year {
+			y = &archiveYear{
+				Year: year,
+				Path: fmt.Sprintf("%s/archive/%d", basePath, year),
+			}
+			years = append(years, y)
+			m = nil
+		}
+		if m == nil || m.Month != month {
+			m = &archiveMonth{
+				Year:  year,
+				Month: month,
+				Path:  fmt.Sprintf("%s/%02d", y.Path, month),
+			}
+			y.Months = append(y.Months, m)
+		}
+		m.Docs = append(m.Docs, d)
+	}
+	return years
+}
+
+// archivePage returns the data of the archive page with the given path,
+// including base: all the articles, or those of a year or of a month.
+func (s *site) archivePage(basePath, path string) (*archiveData, bool) {
+	if path == basePath+"/archive" {
+		return &archiveData{Years: s.archive}, true
+	}
+	for _, y := range s.archive {
+		if path == y.Path {
+			return &archiveData{Year: y.Year, Years: []*archiveYear{y}}, true
+		}
+		for _, m := range y.Months {
+			if path == m.Path {
+				y := &archiveYear{Year: y.Year, Path: y.Path, Months: []*archiveMonth{m}}
+				return &archiveData{Year: y.Year, Month: m.Month, Years: []*archiveYear{y}}, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,232 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/godoc/static"
+)
+
+// Cache-Control of the assets. The fingerprinted URL of an asset changes
+// with its content, so what it serves never changes; the plain path is
+// revalidated with the ETag on each use.
+const (
+	immutableCache = "public, max-age=31536000, immutable"
+	revalidate     = "no-cache"
+)
+
+// fingerprintLen is the number of hex digits of the content hash in the
+// fingerprinted URLs and ETags of the assets.
+const fingerprintLen = 16
+
+// An asset is a static file of the blog, held in memory with its
+// compressed encodings.
+type asset struct {
+	path  string // path without fingerprint, such as /lib/godoc/style.css
+	url   string // path with fingerprint, such as /lib/godoc/style.0123456789abcdef.css
+	ctype string // Content-Type
+	hash  string // fingerprint of the content
+	data  []byte
+	gzip  []byte // gzip encoding of data, if smaller
+	br    []byte // brotli encoding of data, if provided
+}
+
+// assets holds the static files of the blog: the godoc files, served below
+// /lib/godoc/, and the static directory of the blog, served below /static/,
+// whose favicon.ico is also served at /favicon.ico.
+//
+// The templates refer to an asset with the asset function, which returns
+// its fingerprinted URL, cached by browsers for good:
+//
+//	<link rel="stylesheet" href="{{asset "/lib/godoc/style.css"}}">
+//
+// Each asset is compressed with gzip when loaded; the godoc files, which
+// never change, are loaded once for all the Servers that a process builds
+// as it reloads. Go has no brotli encoder,
+// so a brotli encoding is served only when the static directory has one,
+// made with the brotli command, in a file of the same name with a .br
+// extension added.
+type assets struct {
+	paths map[string]*asset // key is path or fingerprinted URL
+}
+
+// godoc holds the assets of the godoc files, once loaded.
+var godoc struct {
+	once   sync.Once
+	assets []*asset
+	err    error
+}
+
+// godocAssets returns the assets of the godoc files, which it loads the
+// first time.
+func godocAssets() ([]*asset, error) {
+	godoc.once.Do(func() {
+		for name, data := range static.Files {
+			x, err := newAsset("/lib/godoc/"+name, []byte(data), nil)
+			if err != nil {
+				godoc.assets, godoc.err = nil, err
+				return
+			}
+			godoc.assets = append(godoc.assets, x)
+		}
+	})
+	return godoc.assets, godoc.err
+}
+
+// newAssets loads the godoc files and the static files in staticFiles.
+func newAssets(staticFiles fs.FS) (*assets, error) {
+	a := &assets{paths: make(map[string]*asset)}
+	godocFiles, err := godocAssets()
+	if err != nil {
+		return nil, err
+	}
+	for _, x := range godocFiles {
+		a.put(x)
+	}
+	if staticFiles == nil {
+		return a, nil
+	}
+	err = fs.WalkDir(staticFiles, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) == ".br" {
+			return err
+		}
+		data, err := fs.ReadFile(staticFiles, p)
+		if err != nil {
+			return err
+		}
+		br, err := fs.ReadFile(staticFiles, p+".br")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		x, err := newAsset("/static/"+p, data, br)
+		if err != nil {
+			return err
+		}
+		a.put(x)
+		if p == "favicon.ico" {
+			// Browsers ask for it without a fingerprint.
+			a.paths["/favicon.ico"] = x
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// newAsset returns the asset at path p with the given content and brotli
+// encoding, if any.
+func newAsset(p string, data, br []byte) (*asset, error) {
+	sum := sha256.Sum256(data)
+	x := &asset{
+		path: p,
+		hash: hex.EncodeToString(sum[:])[:fingerprintLen],
+		data: data,
+		br:   br,
+	}
+	ext := path.Ext(p)
+	x.url = strings.TrimSuffix(p, ext) + "." + x.hash + ext
+	x.ctype = mime.TypeByExtension(ext)
+	if x.ctype == "" {
+		x.ctype = http.DetectContentType(data)
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if buf.Len() < len(data) {
+		x.gzip = buf.Bytes()
+	}
+	return x, nil
+}
+
+// put adds the asset x, at its path and its fingerprinted URL.
+func (a *assets) put(x *asset) {
+	a.paths[x.path] = x
+	a.paths[x.url] = x
+}
+
+// url returns the fingerprinted URL of the asset at path p.
+func (a *assets) url(p string) (string, error) {
+	x, ok := a.paths[p]
+	if !ok {
+		return "", fmt.Errorf("no asset %s", p)
+	}
+	return x.url, nil
+}
+
+// serve serves the asset at the path of r, in the best encoding that the
+// client accepts, and reports whether there is one.
+func (a *assets) serve(w http.ResponseWriter, r *http.Request) bool {
+	x, ok := a.paths[r.URL.Path]
+	if !ok {
+		return false
+	}
+	h := w.Header()
+	if r.URL.Path == x.url {
+		h.Set("Cache-Control", immutableCache)
+	} else {
+		h.Set("Cache-Control", revalidate)
+	}
+	h.Set("Content-Type", x.ctype)
+	body, etag := x.data, x.hash
+	if x.gzip != nil || x.br != nil {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	switch {
+	case x.br != nil && accepts(r, "br"):
+		h.Set("Content-Encoding", "br")
+		body, etag = x.br, x.hash+"-br"
+	case x.gzip != nil && accepts(r, "gzip"):
+		h.Set("Content-Encoding", "gzip")
+		body, etag = x.gzip, x.hash+"-gzip"
+	}
+	h.Set("ETag", strconv.Quote(etag))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+	return true
+}
+
+// accepts reports whether the Accept-Encoding header of r accepts the
+// content coding.
+func accepts(r *http.Request, coding string) bool {
+	for _, s := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(s, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), coding) {
+			continue
+		}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				q, err := strconv.ParseFloat(p[2:], 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+	return false
+}
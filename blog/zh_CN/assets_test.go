@@ -0,0 +1,121 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAssets(t *testing.T) {
+	css := strings.Repeat("body { color: #375eab; }\n", 100)
+	a, err := newAssets(fstest.MapFS{
+		"site.css":    {Data: []byte(css)},
+		"site.css.br": {Data: []byte("brotli")},
+		"favicon.ico": {Data: []byte{0, 0, 1, 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := a.url("/static/site.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(url, "/static/site.") || !strings.HasSuffix(url, ".css") || len(url) != len("/static/site.css")+fingerprintLen+1 {
+		t.Fatalf("url = %q; want fingerprinted /static/site.css", url)
+	}
+	if _, err := a.url("/static/site.css.br"); err == nil {
+		t.Errorf("url of /static/site.css.br succeeded; want the brotli encoding of /static/site.css only")
+	}
+	if _, err := a.url("/lib/godoc/style.css"); err != nil {
+		t.Error(err)
+	}
+
+	get := func(p, acceptEncoding, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", p, nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		if !a.serve(w, r) {
+			t.Fatalf("%s not served", p)
+		}
+		return w
+	}
+
+	w := get(url, "", "")
+	if w.Body.String() != css || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("%s: got encoding %q; want the identity encoding", url, w.Header().Get("Content-Encoding"))
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != immutableCache {
+		t.Errorf("%s: Cache-Control = %q; want %q", url, cc, immutableCache)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/css") {
+		t.Errorf("%s: Content-Type = %q; want text/css", url, ct)
+	}
+
+	w = get("/static/site.css", "gzip, deflate", "")
+	if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("gzip: Content-Encoding = %q", enc)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != revalidate {
+		t.Errorf("/static/site.css: Cache-Control = %q; want %q", cc, revalidate)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil || string(data) != css {
+		t.Errorf("gzip: body does not decompress to the file: %v", err)
+	}
+	etag := w.Header().Get("ETag")
+	if w := get(url, "gzip", etag); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match %s: status %d; want %d", etag, w.Code, http.StatusNotModified)
+	}
+	if w := get(url, "", etag); w.Code != http.StatusOK {
+		t.Errorf("If-None-Match %s without gzip: status %d; want %d", etag, w.Code, http.StatusOK)
+	}
+
+	w = get(url, "gzip, br", "")
+	if enc := w.Header().Get("Content-Encoding"); enc != "br" || w.Body.String() != "brotli" {
+		t.Errorf("br: Content-Encoding = %q, body %q; want the .br file", enc, w.Body.String())
+	}
+	w = get(url, "gzip, br;q=0", "")
+	if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Errorf("br;q=0: Content-Encoding = %q; want gzip", enc)
+	}
+
+	if w := get("/favicon.ico", "", ""); w.Body.Len() != 4 {
+		t.Errorf("/favicon.ico: got %d bytes; want 4", w.Body.Len())
+	}
+	if a.serve(httptest.NewRecorder(), httptest.NewRequest("GET", "/static/nosuch.css", nil)) {
+		t.Errorf("/static/nosuch.css served")
+	}
+}
+
+func TestGodocAssetsShared(t *testing.T) {
+	a1, err := newAssets(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := newAssets(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const p = "/lib/godoc/style.css"
+	if a1.paths[p] == nil || a1.paths[p] != a2.paths[p] {
+		t.Errorf("the assets of %s are loaded again; want them loaded once", p)
+	}
+}
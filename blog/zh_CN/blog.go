@@ -0,0 +1,79 @@
+// Copyright 2013 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command blog is a web server for the Go blog. The articles, templates
+// and static files are built into the binary, which serves the blog on its
+// own; the -dir flag serves them from a directory instead, to work on
+// them. The settings of the blog, such as its host name and base URL, are
+// read from config.json, also built in, and may be overridden with the
+// -config flag. The server can also export the blog as static files, with
+// the -export flag.
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// The files of the blog, built into the binary. The content directory
+// _tr holds the markers of translated blocks, which would be left out
+// for its leading underscore if it were not named.
+//
+//go:embed config.json content content/_tr template static
+var embedded embed.FS
+
+// configFile is the name of the built-in settings of the blog.
+const configFile = "config.json"
+
+// readConfig reads the settings of the blog from the built-in config.json,
+// then from file, if set, whose settings override the built-in ones.
+func readConfig(file string) (Config, error) {
+	var cfg Config
+	data, err := embedded.ReadFile(configFile)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %v", configFile, err)
+	}
+	if file == "" {
+		return cfg, nil
+	}
+	data, err = ioutil.ReadFile(file)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %v", file, err)
+	}
+	return cfg, nil
+}
+
+// files returns the content, template and static directories of the blog:
+// those in dir, if set, or those built into the binary.
+func files(dir string) (content, templates, staticFiles fs.FS, err error) {
+	if dir != "" {
+		for _, name := range []string{"content", "template", "static"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		return os.DirFS(filepath.Join(dir, "content")),
+			os.DirFS(filepath.Join(dir, "template")),
+			os.DirFS(filepath.Join(dir, "static")), nil
+	}
+	sub := func(name string) fs.FS {
+		fsys, err := fs.Sub(embedded, name)
+		if err != nil {
+			panic(err) // the name is valid
+		}
+		return fsys
+	}
+	return sub("content"), sub("template"), sub("static"), nil
+}
@@ -0,0 +1,360 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// A book holds articles of the blog, chosen to be read offline, rendered in
+// one language.
+type book struct {
+	Locale   string
+	Tag      *tagInfo // tag of the articles, if chosen by tag
+	ID       string   // unique identifier of the EPUB
+	Modified time.Time
+	Chapters []*chapter
+	Images   []*bookImage // images of the EPUB
+}
+
+// A chapter is an article of a book.
+type chapter struct {
+	ID   string // slug of the article
+	Doc  *Doc
+	Body template.HTML // article as XHTML, with the images of the book
+}
+
+// A bookImage is an image of the articles, stored in the EPUB.
+type bookImage struct {
+	Name string // name in the EPUB
+	Type string // media type
+	data []byte
+}
+
+// writeBook writes the articles of the tag, if set, and those of the slugs,
+// rendered in lang, to file: an EPUB, or with an .html extension a single
+// HTML page holding the images, to print or save as PDF. The articles of
+// the tag come oldest first, after those of the slugs, in their order.
+func (s *Server) writeBook(file, lang, tag string, slugs []string) error {
+	site, ok := s.sites[lang]
+	if !ok {
+		return fmt.Errorf("unknown language %s", lang)
+	}
+	b := &book{Locale: s.cfg.Locale}
+	var docs []*Doc
+	seen := make(map[*Doc]bool)
+	for _, slug := range slugs {
+		d, ok := site.docPaths["/"+slug]
+		if !ok {
+			return fmt.Errorf("unknown article %s", slug)
+		}
+		if !seen[d] {
+			seen[d] = true
+			docs = append(docs, d)
+		}
+	}
+	if tag != "" {
+		tagged, ok := site.docTags[tag]
+		if !ok {
+			return fmt.Errorf("unknown tag %s", tag)
+		}
+		t := s.tag(tag)
+		b.Tag = &t
+		for i := len(tagged) - 1; i >= 0; i-- {
+			if d := tagged[i]; !seen[d] {
+				seen[d] = true
+				docs = append(docs, d)
+			}
+		}
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("no articles; give a tag or slugs")
+	}
+
+	// The book is the same as long as its articles are.
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", lang)
+	for _, d := range docs {
+		fmt.Fprintf(h, "%s\n", d.Path)
+		if d.Time.After(b.Modified) {
+			b.Modified = d.Time
+		}
+	}
+	sum := h.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x50 // version 5
+	sum[8] = sum[8]&0x3f | 0x80 // variant
+	b.ID = fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+	b.Modified = b.Modified.UTC()
+
+	epub := path.Ext(file) != ".html"
+	images := make(map[string]string) // content path to src in the book
+	for _, d := range docs {
+		c := &chapter{ID: strings.TrimPrefix(d.Path, s.cfg.BasePath+"/"), Doc: d}
+		body, err := xhtml(string(d.HTML), func(src string) (string, error) {
+			name, ok := s.contentPath(src)
+			if !ok {
+				return src, nil // elsewhere on the web
+			}
+			if src, ok := images[name]; ok {
+				return src, nil
+			}
+			data, err := fs.ReadFile(s.cfg.Content, name)
+			if err != nil {
+				return "", err
+			}
+			typ := mime.TypeByExtension(path.Ext(name))
+			if typ == "" {
+				return "", fmt.Errorf("image %s: unknown type", name)
+			}
+			if epub {
+				img := &bookImage{Name: "images/" + name, Type: typ, data: data}
+				b.Images = append(b.Images, img)
+				images[name] = img.Name
+			} else {
+				images[name] = "data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(data)
+			}
+			return images[name], nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %v", c.ID, err)
+		}
+		c.Body = template.HTML(body)
+		b.Chapters = append(b.Chapters, c)
+	}
+
+	t, err := template.New("").Funcs(funcMap).Funcs(template.FuncMap{
+		"tag": s.tag,
+		"msg": s.catalog.msg,
+	}).ParseFS(s.cfg.Templates, "book.tmpl")
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if epub {
+		err = b.writeEPUB(&buf, t)
+	} else {
+		err = t.ExecuteTemplate(&buf, "book-html", b)
+	}
+	if err != nil {
+		return err
+	}
+	return writeFile(file, buf.Bytes())
+}
+
+// contentPath returns the path in the content directory of the file at
+// src, a link of an article, and whether it is one.
+func (s *Server) contentPath(src string) (string, bool) {
+	if strings.Contains(src, ":") || strings.HasPrefix(src, "//") {
+		return "", false
+	}
+	// The articles are served at the top of BasePath.
+	p := strings.TrimPrefix(src, s.cfg.BasePath+"/")
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	return p, p != ""
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+	<rootfiles>
+		<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+	</rootfiles>
+</container>
+`
+
+// writeEPUB writes b to w as an EPUB 3 file, with the templates of t.
+func (b *book) writeEPUB(w io.Writer, t *template.Template) error {
+	zw := zip.NewWriter(w)
+	add := func(name string, method uint16, write func(io.Writer) error) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: b.Modified})
+		if err != nil {
+			return err
+		}
+		return write(f)
+	}
+	data := func(d []byte) func(io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := w.Write(d)
+			return err
+		}
+	}
+	// exec executes a template of an XML file, whose declaration
+	// html/template would escape.
+	exec := func(name string, v interface{}) func(io.Writer) error {
+		return func(w io.Writer) error {
+			if _, err := io.WriteString(w, xml.Header); err != nil {
+				return err
+			}
+			return t.ExecuteTemplate(w, name, v)
+		}
+	}
+
+	// The mimetype comes first, uncompressed, for tools that look for it.
+	if err := add("mimetype", zip.Store, data([]byte("application/epub+zip"))); err != nil {
+		return err
+	}
+	if err := add("META-INF/container.xml", zip.Deflate, data([]byte(epubContainer))); err != nil {
+		return err
+	}
+	if err := add("OEBPS/content.opf", zip.Deflate, exec("book-opf", b)); err != nil {
+		return err
+	}
+	if err := add("OEBPS/nav.xhtml", zip.Deflate, exec("book-nav", b)); err != nil {
+		return err
+	}
+	css := func(w io.Writer) error { return t.ExecuteTemplate(w, "book-css", nil) }
+	if err := add("OEBPS/style.css", zip.Deflate, css); err != nil {
+		return err
+	}
+	for _, c := range b.Chapters {
+		page := struct {
+			*book
+			Chapter *chapter
+		}{b, c}
+		if err := add("OEBPS/"+c.ID+".xhtml", zip.Deflate, exec("book-chapter", page)); err != nil {
+			return err
+		}
+	}
+	for _, img := range b.Images {
+		// Images are compressed already.
+		if err := add("OEBPS/"+img.Name, zip.Store, data(img.data)); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// voidElements are the HTML elements that have no end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// script matches the scripts of an article.
+var script = regexp.MustCompile(`(?is)<script\b.*?</script\s*>`)
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// xhtml converts the HTML of an article to XHTML, as EPUB requires, which
+// also serves as HTML. The src of each image is replaced by that returned
+// by image. Frames, such as those of videos, become links to their pages,
+// and scripts are left out. End tags that close no element, as those of
+// broken blocks, are dropped, and the elements left open are closed.
+func xhtml(src string, image func(src string) (string, error)) (string, error) {
+	// Scripts are not XML, so they are removed before decoding.
+	d := xml.NewDecoder(strings.NewReader(script.ReplaceAllString(src, "")))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	var (
+		buf  bytes.Buffer
+		open []string // elements not closed yet
+	)
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if name == "iframe" {
+				u := ""
+				for _, a := range t.Attr {
+					if strings.ToLower(a.Name.Local) == "src" {
+						u = xmlEscaper.Replace(a.Value)
+					}
+				}
+				fmt.Fprintf(&buf, `<p class="link"><a href="%s">%s</a></p>`, u, u)
+				if err := skipElement(d, name); err != nil {
+					return "", err
+				}
+				continue
+			}
+			buf.WriteString("<" + name)
+			for _, a := range t.Attr {
+				attr, val := strings.ToLower(a.Name.Local), a.Value
+				if a.Name.Space != "" || strings.ContainsAny(attr, ":") {
+					continue
+				}
+				if name == "img" && attr == "src" {
+					if val, err = image(val); err != nil {
+						return "", err
+					}
+				}
+				fmt.Fprintf(&buf, ` %s="%s"`, attr, xmlEscaper.Replace(val))
+			}
+			if voidElements[name] {
+				buf.WriteString("/>")
+			} else {
+				buf.WriteString(">")
+				open = append(open, name)
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			i := len(open) - 1
+			for i >= 0 && open[i] != name {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			for len(open) > i {
+				buf.WriteString("</" + open[len(open)-1] + ">")
+				open = open[:len(open)-1]
+			}
+		case xml.CharData:
+			buf.WriteString(xmlEscaper.Replace(string(t)))
+		}
+	}
+	for len(open) > 0 {
+		buf.WriteString("</" + open[len(open)-1] + ">")
+		open = open[:len(open)-1]
+	}
+	return buf.String(), nil
+}
+
+// skipElement reads the tokens of d up to the end of the element name,
+// whose start it has just read.
+func skipElement(d *xml.Decoder, name string) error {
+	depth := 0
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if strings.ToLower(t.Name.Local) == name {
+				depth++
+			}
+		case xml.EndElement:
+			if strings.ToLower(t.Name.Local) == name {
+				if depth == 0 {
+					return nil
+				}
+				depth--
+			}
+		}
+	}
+}
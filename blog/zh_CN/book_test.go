@@ -0,0 +1,117 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXHTML(t *testing.T) {
+	image := func(src string) (string, error) { return "images/" + src, nil }
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{`<p>a<br>b&nbsp;&amp;</p>`, "<p>a<br/>b &amp;</p>"},
+		{`<div class="image"><img src="gopher.png" height=100></div>`, `<div class="image"><img src="images/gopher.png" height="100"/></div>`},
+		{`<iframe src="https://www.youtube.com/embed/x?a=1&b=2" allowfullscreen></iframe>`, `<p class="link"><a href="https://www.youtube.com/embed/x?a=1&amp;b=2">https://www.youtube.com/embed/x?a=1&amp;b=2</a></p>`},
+		{`<script>if (a < b) {}</script><p>text</p>`, `<p>text</p>`},
+		{`<div><p>open</div></div><p>x`, `<div><p>open</p></div><p>x</p>`},
+		{`<pre>a &lt; b
+c</pre>`, "<pre>a &lt; b\nc</pre>"},
+	} {
+		out, err := xhtml(tt.in, image)
+		if err != nil {
+			t.Errorf("xhtml(%q): %v", tt.in, err)
+			continue
+		}
+		if out != tt.out {
+			t.Errorf("xhtml(%q) = %q; want %q", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestWriteBook(t *testing.T) {
+	s, err := NewServer(Config{
+		Content:     os.DirFS(contentDir),
+		Templates:   os.DirFS(templateDir),
+		UpstreamURL: "https://blog.golang.org",
+		Locale:      "zh-CN",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "book.epub")
+	if err := s.writeBook(file, langBoth, "", []string{"4years", "package-names"}); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.OpenReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer z.Close()
+	if f := z.File[0]; f.Name != "mimetype" || f.Method != zip.Store {
+		t.Errorf("first file %s, method %d; want mimetype, stored", f.Name, f.Method)
+	}
+	names := make(map[string]bool)
+	for _, f := range z.File {
+		names[f.Name] = true
+		if !strings.HasSuffix(f.Name, ".xhtml") && !strings.HasSuffix(f.Name, ".opf") {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := xml.NewDecoder(r)
+		for {
+			if _, err := d.Token(); err != nil {
+				if err != io.EOF {
+					t.Errorf("%s: %v", f.Name, err)
+				}
+				break
+			}
+		}
+		r.Close()
+	}
+	for _, name := range []string{
+		"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml",
+		"OEBPS/4years.xhtml", "OEBPS/package-names.xhtml", "OEBPS/images/4years-gopher.png",
+	} {
+		if !names[name] {
+			t.Errorf("no %s in the EPUB", name)
+		}
+	}
+
+	file = filepath.Join(dir, "book.html")
+	if err := s.writeBook(file, langZH, "package", nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<a href="#article-package-names">`, `id="article-package-names"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s has no %s", file, want)
+		}
+	}
+
+	if err := s.writeBook(file, langZH, "no-such-tag", nil); err == nil {
+		t.Errorf("writeBook with an unknown tag succeeded")
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/present"
+)
+
+// checkContent checks the articles in the content file system fsys, and
+// returns a diagnostic of the form file:line: message for each problem,
+// with the name of the file in fsys.
+//
+// It checks that the header of each article holds its title, with the
+// English title commented out above a Chinese one, a date, tags with
+// Chinese labels, and authors; that the blocks of original and translated
+// text are balanced; and that the files and regions named by the .code,
+// .play, .html, .image and .iframe commands exist.
+func checkContent(fsys fs.FS) ([]string, error) {
+	labels, err := readTagLabels(fsys, tagFile)
+	if err != nil {
+		return nil, err
+	}
+	var diags []string
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path.Ext(p) != ".article" {
+			return nil
+		}
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		diags = append(diags, checkArticle(fsys, p, src, labels)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diags, nil
+}
+
+// An articleChecker collects the diagnostics of an article.
+type articleChecker struct {
+	fsys   fs.FS // content
+	file   string
+	labels tagLabels
+	diags  []diag
+}
+
+// A diag is a diagnostic about a line of an article.
+type diag struct {
+	line int
+	msg  string
+}
+
+func (c *articleChecker) errorf(lineno int, format string, args ...interface{}) {
+	c.diags = append(c.diags, diag{lineno, fmt.Sprintf(format, args...)})
+}
+
+// checkArticle checks the article src, read from file in the content
+// file system fsys.
+func checkArticle(fsys fs.FS, file string, src []byte, labels tagLabels) []string {
+	c := &articleChecker{fsys: fsys, file: file, labels: labels}
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	n := headerEnd(lines)
+	c.checkHeader(lines[:n])
+	if _, err := splitBlocks(lines[n:], n); err != nil {
+		if e, ok := err.(*blockError); ok {
+			c.errorf(e.line, "%s", e.msg)
+		} else {
+			c.errorf(n+1, "%v", err)
+		}
+	}
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") {
+			c.checkCommand(i+1, line)
+		}
+	}
+	sort.Stable(byLine(c.diags))
+	var diags []string
+	for _, d := range c.diags {
+		diags = append(diags, fmt.Sprintf("%s:%d: %s", file, d.line, d.msg))
+	}
+	return diags
+}
+
+// checkHeader checks the header of an article: its title, date, tags and
+// publication, then its authors. An article whose original names no author
+// says so in a comment in place of the authors.
+func (c *articleChecker) checkHeader(header []string) {
+	if len(header) == 0 || strings.TrimSpace(header[0]) == "" {
+		c.errorf(1, "missing title")
+		return
+	}
+	n := 0
+	if strings.HasPrefix(header[0], "#") {
+		if strings.TrimSpace(header[0][1:]) == "" {
+			c.errorf(1, "empty English title")
+		}
+		n = 1
+		if n == len(header) || strings.TrimSpace(header[n]) == "" {
+			c.errorf(n+1, "missing Chinese title below English title")
+			return
+		}
+		if !titleTranslated(header[n : n+1]) {
+			c.errorf(n+1, "title %q below English title is not in Chinese", header[n])
+		}
+	} else if titleTranslated(header[:1]) {
+		c.errorf(1, "Chinese title has no English title commented out above it")
+	}
+
+	date := false
+	for n++; n < len(header) && strings.TrimSpace(header[n]) != ""; n++ {
+		line := header[n]
+		switch {
+		case strings.HasPrefix(line, "#"):
+			// comment
+		case strings.HasPrefix(line, draftPrefix):
+			// note for the translators
+		case strings.HasPrefix(line, publishPrefix):
+			if _, err := parsePublish(line[len(publishPrefix):]); err != nil {
+				c.errorf(n+1, "%v", err)
+			}
+		case strings.HasPrefix(line, "Tags:"):
+			for _, tag := range strings.Split(line[len("Tags:"):], ",") {
+				tag = strings.TrimSpace(tag)
+				if tag == "" {
+					c.errorf(n+1, "empty tag")
+				} else if _, ok := c.labels[tag]; !ok {
+					c.errorf(n+1, "tag %s has no label in %s", tag, tagFile)
+				}
+			}
+		case isDate(line):
+			if date {
+				c.errorf(n+1, "second date")
+			}
+			date = true
+		default:
+			c.errorf(n+1, "unexpected header line %q; want date, Tags:, Draft: or Publish:", line)
+		}
+	}
+	if !date {
+		c.errorf(n+1, "missing date before blank line")
+	}
+
+	for n < len(header) && strings.TrimSpace(header[n]) == "" {
+		n++
+	}
+	if n == len(header) {
+		c.errorf(n+1, "missing author")
+	}
+}
+
+// isDate reports whether line is a date, in a format present accepts.
+func isDate(line string) bool {
+	doc, err := present.Parse(strings.NewReader("Title\n"+line+"\n\nAuthor\n\n* Section\n"), "date", 0)
+	return err == nil && !doc.Time.IsZero()
+}
+
+// checkCommand checks the files named by the present command on line
+// lineno.
+func (c *articleChecker) checkCommand(lineno int, line string) {
+	args := strings.Fields(line)
+	switch args[0] {
+	case ".code", ".play", ".html", ".image", ".iframe":
+	default:
+		return
+	}
+	// Let present parse the command alone, which reads the files
+	// it names and finds the regions in them.
+	src := "Title\n\nAuthor\n\n* Section\n\n" + line + "\n"
+	if _, err := presentContext(c.fsys).Parse(strings.NewReader(src), c.file, 0); err != nil {
+		msg := strings.TrimPrefix(err.Error(), c.file)
+		c.errorf(lineno, "%s", cmdErrPrefix.ReplaceAllString(msg, ""))
+		return
+	}
+	if (args[0] == ".image" || args[0] == ".iframe") && len(args) > 1 {
+		c.checkURL(lineno, args[1])
+	}
+}
+
+// cmdErrPrefix matches the line number that present puts at the start of
+// some of its errors, after the file name.
+var cmdErrPrefix = regexp.MustCompile(`^:\d+:? `)
+
+// checkURL checks that the file named by the URL u of an image or iframe
+// exists, if it is served by the blog.
+func (c *articleChecker) checkURL(lineno int, u string) {
+	if strings.Contains(u, "://") || strings.HasPrefix(u, "//") {
+		return
+	}
+	name := path.Join(path.Dir(c.file), u)
+	if strings.HasPrefix(u, "/") {
+		name = path.Clean(u[1:])
+	}
+	if _, err := fs.Stat(c.fsys, name); err != nil {
+		c.errorf(lineno, "%s: no such file", u)
+	}
+}
+
+// byLine sorts diagnostics by line.
+type byLine []diag
+
+func (s byLine) Len() int           { return len(s) }
+func (s byLine) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byLine) Less(i, j int) bool { return s[i].line < s[j].line }
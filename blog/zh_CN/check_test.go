@@ -0,0 +1,94 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const checkedArticle = `#Go slices
+Go 的切片
+4 Mayy 2015
+Tags: slice, bogus
+
+Rob Pike
+
+* 
+
+#en
+Slices are views of arrays.
+#zh
+切片是数组的视图.
+#end
+
+#en
+.code code.go /START/,/END/
+.code code.go /NOSUCH/
+.play gone.go
+.image missing.png
+.image //golang.org/gopher.png
+.html note.html
+#end
+#end
+`
+
+const checkedCode = `package main
+
+// START OMIT
+func main() {}
+// END OMIT
+`
+
+func TestCheckArticle(t *testing.T) {
+	fsys := fstest.MapFS{"code.go": {Data: []byte(checkedCode)}}
+	const file = "slices.article"
+	labels := tagLabels{"slice": "切片"}
+	diags := checkArticle(fsys, file, []byte(checkedArticle), labels)
+	want := []string{
+		`3: unexpected header line "4 Mayy 2015"; want date, Tags:, Draft: or Publish:`,
+		"4: tag bogus has no label in tags.txt",
+		"5: missing date before blank line",
+		"18: no match for NOSUCH",
+		"19: open gone.go: file does not exist",
+		"20: missing.png: no such file",
+		"22: open note.html: file does not exist",
+		"24: unexpected end of block",
+	}
+	for i := range want {
+		want[i] = file + ":" + want[i]
+	}
+	if strings.Join(diags, "\n") != strings.Join(want, "\n") {
+		t.Errorf("checkArticle diagnostics:\n%s\nwant:\n%s", strings.Join(diags, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCheckHeader(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		diags  []string
+	}{
+		{"Go slices\n4 May 2015\n\nRob Pike\n", nil},
+		{"#Go slices\nGo 的切片\n4 May 2015\nTags: slice\n\nRob Pike\n", nil},
+		{"Go 的切片\n4 May 2015\n\nRob Pike\n", []string{"1: Chinese title has no English title commented out above it"}},
+		{"#Go slices\nGo slices\n4 May 2015\n\nRob Pike\n", []string{`2: title "Go slices" below English title is not in Chinese`}},
+		{"#Go slices\n\n4 May 2015\n", []string{"2: missing Chinese title below English title"}},
+		{"Go slices\n4 May 2015\n6 May 2015\n\n", []string{"3: second date", "6: missing author"}},
+		{"Go slices\n4 May 2015\nDraft: 校对中\nPublish: 2016-05-01 08:00 +0800\n\nRob Pike\n", nil},
+		{"Go slices\n4 May 2015\nPublish: 1 May 2016\n\nRob Pike\n", []string{`3: publication time "1 May 2016" is not YYYY-MM-DD [HH:MM [-0700]]`}},
+	} {
+		c := &articleChecker{labels: tagLabels{"slice": "切片"}}
+		c.checkHeader(strings.Split(tt.header, "\n"))
+		var diags []string
+		for _, d := range c.diags {
+			diags = append(diags, fmt.Sprintf("%d: %s", d.line, d.msg))
+		}
+		if strings.Join(diags, "\n") != strings.Join(tt.diags, "\n") {
+			t.Errorf("checkHeader(%q) = %q; want %q", tt.header, diags, tt.diags)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// export writes the blog to dir as static files, so that it can be served
+// by any web server from BasePath. The pages are rendered in the default
+// language, and written to the path at which they are served, with an
+// .html extension, except the home page and the article index, which are
+// written to index.html and index/index.html. Static hosts commonly serve
+// these files at the paths without the extension. Feeds, the sitemap, the
+// files the articles refer to and static files are copied as they are, and
+// each redirect becomes a page that refreshes to its article.
+func (s *Server) export(dir string) error {
+	s.exporting = true
+	defer func() { s.exporting = false }()
+
+	site := s.sites[defaultLang]
+	pages := []string{
+		"/", "/index", "/translations", "/tags", "/archive",
+		"/feed.atom", "/feeds/posts/default", "/.json",
+		"/translated.atom", "/translated.json", "/sitemap.xml",
+	}
+	for p := range site.docPaths {
+		pages = append(pages, p)
+	}
+	for _, tag := range site.tags {
+		p := "/tag/" + tag
+		pages = append(pages, p, p+".atom", p+".json")
+	}
+	for _, y := range site.archive {
+		pages = append(pages, strings.TrimPrefix(y.Path, s.cfg.BasePath))
+		for _, m := range y.Months {
+			pages = append(pages, strings.TrimPrefix(m.Path, s.cfg.BasePath))
+		}
+	}
+	sort.Strings(pages)
+	for _, p := range pages {
+		if err := s.exportPage(dir, p); err != nil {
+			return err
+		}
+	}
+
+	if err := s.exportRedirects(dir); err != nil {
+		return err
+	}
+
+	// The files the articles refer to are served as is, below BasePath.
+	err := copyDir(dir, s.cfg.Content, contentAsset)
+	if err != nil {
+		return err
+	}
+	return s.exportAssets(dir)
+}
+
+// contentAsset reports whether the file name of the content directory is
+// one to which the articles refer, such as an image or a program, rather
+// than an article, a fragment that articles include with .html, such as
+// those of _tr, or a data file of the server.
+func contentAsset(name string) bool {
+	switch name {
+	case redirectFile, tagFile, upstreamFile:
+		return false
+	}
+	switch path.Ext(name) {
+	case ".article", ".html":
+		return false
+	}
+	return true
+}
+
+// exportAssets writes the static files to dir, at their paths and at
+// their fingerprinted URLs, each with its compressed encodings in files
+// of the same name with a .gz or .br extension added, which some web
+// servers serve to the clients that accept them.
+func (s *Server) exportAssets(dir string) error {
+	for p, x := range s.assets.paths {
+		name := filepath.Join(dir, filepath.FromSlash(p))
+		if err := writeFile(name, x.data); err != nil {
+			return err
+		}
+		if x.gzip != nil {
+			if err := writeFile(name+".gz", x.gzip); err != nil {
+				return err
+			}
+		}
+		if x.br != nil {
+			if err := writeFile(name+".br", x.br); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportFile returns the name of the file to which the page p, a path
+// without BasePath, is exported.
+func exportFile(dir, p string) string {
+	switch {
+	case p == "/" || p == "/index":
+		p = path.Join(p, "index.html")
+	case path.Ext(p) == "" && !strings.HasPrefix(p, "/feeds/"):
+		p += ".html"
+	}
+	return filepath.Join(dir, filepath.FromSlash(p))
+}
+
+// exportPage renders the page p, a path without BasePath, to its file
+// in dir.
+func (s *Server) exportPage(dir, p string) error {
+	r, err := http.NewRequest("GET", s.cfg.BasePath+p, nil)
+	if err != nil {
+		return err
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("exporting %s: %d %s", p, w.Code, http.StatusText(w.Code))
+	}
+	return writeFile(exportFile(dir, p), w.Body.Bytes())
+}
+
+const redirectPage = `<!DOCTYPE html>
+<html>
+<head>
+	<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
+	<meta http-equiv="refresh" content="0; url={{.}}">
+	<link rel="canonical" href="{{.}}">
+	<title>{{msg "moved.title"}}</title>
+</head>
+<body>
+	<p>{{msg "moved.text" .}}</p>
+</body>
+</html>
+`
+
+// exportRedirects writes a page that refreshes to the new location of each
+// redirected path: the old paths of the redirect table, and the paths of the
+// articles below its prefixes.
+func (s *Server) exportRedirects(dir string) error {
+	page, err := template.New("").Funcs(template.FuncMap{"msg": s.catalog.msg}).Parse(redirectPage)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for from := range s.redirect.paths {
+		paths = append(paths, from)
+	}
+	for _, prefix := range s.redirect.prefixes {
+		paths = append(paths, prefix)
+		for p := range s.sites[defaultLang].docPaths {
+			paths = append(paths, prefix+p)
+		}
+	}
+	sort.Strings(paths)
+	for _, from := range paths {
+		slug, ok := s.redirect.lookup(from, s.exists)
+		if !ok {
+			continue
+		}
+		name := exportFile(dir, from)
+		if slug == "" {
+			// A prefix, which is a directory.
+			name = filepath.Join(dir, filepath.FromSlash(from), "index.html")
+		}
+		f, err := create(name)
+		if err != nil {
+			return err
+		}
+		err = page.Execute(f, s.cfg.BasePath+"/"+slug)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyDir copies the files in src for which match is true to dst,
+// keeping their paths.
+func copyDir(dst string, src fs.FS, match func(name string) bool) error {
+	return fs.WalkDir(src, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !match(p) {
+			return nil
+		}
+		in, err := src.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := create(filepath.Join(dst, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, in)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
+
+// create creates the file name and the directories containing it.
+func create(name string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(name)
+}
+
+// writeFile writes data to the file name, creating the directories
+// containing it.
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0644)
+}
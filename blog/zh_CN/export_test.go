@@ -0,0 +1,28 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestContentAsset(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		asset bool
+	}{
+		{"4years-gopher.png", true},
+		{"pipelines/serial.go", true},
+		{"cover/pkg.cover", true},
+		{"package-names.article", false},
+		{"_tr/div_begin_en.html", false},
+		{"normalization/table1.html", false},
+		{redirectFile, false},
+		{tagFile, false},
+		{upstreamFile, false},
+	} {
+		if asset := contentAsset(tt.name); asset != tt.asset {
+			t.Errorf("contentAsset(%q) = %v; want %v", tt.name, asset, tt.asset)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/blog/atom"
+	"golang.org/x/tools/present"
+)
+
+// The feeds are in Chinese: they hold the articles as rendered in langZH,
+// with alternate links to the English originals.
+const (
+	feedLang     = "zh-CN"
+	originalLang = "en"
+)
+
+// A feed holds the pre-rendered Atom and JSON feeds of a list of articles.
+type feed struct {
+	atom []byte
+	json []byte
+}
+
+// newFeed renders the feeds of the first FeedArticles docs. The Atom feed
+// is served at path.
+func (s *Server) newFeed(title, path string, docs []*Doc) (*feed, error) {
+	if len(docs) > s.cfg.FeedArticles {
+		docs = docs[:s.cfg.FeedArticles]
+	}
+	f := new(feed)
+	var err error
+	f.atom, err = s.renderAtomFeed(title, path, docs)
+	if err != nil {
+		return nil, err
+	}
+	f.json, err = renderJSONFeed(docs)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// serveAtom serves the Atom feed.
+func (f *feed) serveAtom(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", "application/atom+xml; charset=utf-8")
+	w.Write(f.atom)
+}
+
+// serveJSON serves the JSON feed, wrapped in a call to the function named
+// by the jsonp parameter, if any.
+func (f *feed) serveJSON(w http.ResponseWriter, r *http.Request) {
+	if p := r.FormValue("jsonp"); validJSONPFunc.MatchString(p) {
+		w.Header().Set("Content-type", "application/javascript; charset=utf-8")
+		fmt.Fprintf(w, "%v(%s)", p, f.json)
+		return
+	}
+	w.Header().Set("Content-type", "application/json; charset=utf-8")
+	w.Write(f.json)
+}
+
+// feedID returns the Atom ID of the blog. The entries have the same ID in
+// every feed.
+func (s *Server) feedID() string {
+	return "tag:" + s.cfg.Hostname + ",2013:" + s.cfg.Hostname
+}
+
+// renderAtomFeed generates an XML Atom feed of docs.
+func (s *Server) renderAtomFeed(title, path string, docs []*Doc) ([]byte, error) {
+	var updated time.Time
+	if len(docs) > 0 {
+		updated = docs[0].Time
+	}
+	id := s.feedID()
+	feed := atom.Feed{
+		Title:   title,
+		ID:      id + strings.TrimSuffix(path, ".atom"),
+		Updated: atom.Time(updated),
+		Link: []atom.Link{{
+			Rel:  "self",
+			Href: s.cfg.BaseURL + path,
+		}},
+	}
+	for _, doc := range docs {
+		e := &atom.Entry{
+			Title: doc.Title,
+			ID:    id + doc.Path,
+			Link: []atom.Link{{
+				Rel:      "alternate",
+				Href:     doc.Permalink,
+				HrefLang: feedLang,
+			}, {
+				Rel:      "alternate",
+				Href:     doc.Original,
+				HrefLang: originalLang,
+			}},
+			Published: atom.Time(doc.Time),
+			Updated:   atom.Time(doc.Time),
+			Summary: &atom.Text{
+				Type: "html",
+				Body: summary(doc),
+			},
+			Content: &atom.Text{
+				Type: "html",
+				Body: string(doc.HTML),
+			},
+			Author: &atom.Person{
+				Name: authors(doc.Authors),
+			},
+		}
+		feed.Entry = append(feed.Entry, e)
+	}
+	return xml.Marshal(&feed)
+}
+
+type jsonItem struct {
+	Title    string
+	Link     string
+	Original string // URL of the English original
+	Time     time.Time
+	Summary  string
+	Content  string
+	Author   string
+}
+
+// renderJSONFeed generates a JSON feed of docs.
+func renderJSONFeed(docs []*Doc) ([]byte, error) {
+	var feed []jsonItem
+	for _, doc := range docs {
+		item := jsonItem{
+			Title:    doc.Title,
+			Link:     doc.Permalink,
+			Original: doc.Original,
+			Time:     doc.Time,
+			Summary:  summary(doc),
+			Content:  string(doc.HTML),
+			Author:   authors(doc.Authors),
+		}
+		feed = append(feed, item)
+	}
+	return json.Marshal(feed)
+}
+
+// summary returns the first paragraph of text from the provided Doc.
+// Translated articles often start with an empty section, so the first
+// paragraph is looked for in all sections.
+func summary(d *Doc) string {
+	return firstParagraph(d.Sections)
+}
+
+func firstParagraph(sections []present.Section) string {
+	for _, sec := range sections {
+		for _, elem := range sec.Elem {
+			switch elem := elem.(type) {
+			case present.Section:
+				if p := firstParagraph([]present.Section{elem}); p != "" {
+					return p
+				}
+			case present.Text:
+				if elem.Pre {
+					continue
+				}
+				var buf bytes.Buffer
+				for _, s := range elem.Lines {
+					buf.WriteString(string(present.Style(s)))
+					buf.WriteByte('\n')
+				}
+				return buf.String()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,12 @@
+module golang.org/x/blog
+
+go 1.22
+
+require (
+	github.com/gorilla/context v1.1.2
+	golang.org/x/net v0.27.0
+	golang.org/x/tools v0.23.0
+	gopkg.in/tomb.v2 v2.0.0-20161208151619-d5d1b5820637
+)
+
+require github.com/yuin/goldmark v1.5.4 // indirect
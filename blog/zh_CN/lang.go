@@ -0,0 +1,307 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Languages in which the articles are rendered.
+const (
+	langZH   = "zh"   // translation, with the original where it is missing
+	langEN   = "en"   // original
+	langBoth = "both" // original and translation side by side
+)
+
+var langs = []string{langZH, langEN, langBoth}
+
+// defaultLang is the language of a reader who has not chosen one.
+const defaultLang = langZH
+
+const langCookie = "lang"
+
+func validLang(lang string) bool {
+	for _, l := range langs {
+		if lang == l {
+			return true
+		}
+	}
+	return false
+}
+
+// requestLang returns the language in which to serve r: the lang parameter
+// of the request, which is remembered in a cookie, or the one chosen before.
+func requestLang(w http.ResponseWriter, r *http.Request) string {
+	if lang := r.FormValue("lang"); validLang(lang) {
+		http.SetCookie(w, &http.Cookie{
+			Name:   langCookie,
+			Value:  lang,
+			Path:   "/",
+			MaxAge: 365 * 24 * 60 * 60,
+		})
+		return lang
+	}
+	if c, err := r.Cookie(langCookie); err == nil && validLang(c.Value) {
+		return c.Value
+	}
+	return defaultLang
+}
+
+// langURLs returns the URL of the page at u in each language, for the
+// language switch: its path and query, with the lang parameter replaced.
+func langURLs(u *url.URL) map[string]string {
+	urls := make(map[string]string)
+	for _, lang := range langs {
+		q := u.Query()
+		q.Set("lang", lang)
+		v := url.URL{Path: u.Path, RawQuery: q.Encode()}
+		urls[lang] = v.String()
+	}
+	return urls
+}
+
+// In translated articles, each original paragraph is followed by its
+// translation, and both are marked with comment lines, which present
+// ignores:
+//
+//	#en
+//	Go code is organized into packages.
+//	#zh
+//	Go 代码通过包来组织.
+//	#end
+//
+// A block may also hold the original or the translation alone. Text outside
+// of the blocks is shared by both languages.
+const (
+	markEN  = "#en"
+	markZH  = "#zh"
+	markEnd = "#end"
+)
+
+// Older articles enclose the original in a pair of .html commands including
+// _tr/div_begin_en.html and _tr/div_end.html, and the translation in
+// _tr/div_begin_zh_CN.html and _tr/div_end.html. These includes wrap each
+// language in a <div> when the article is rendered with both languages.
+const (
+	beginEN  = ".html _tr/div_begin_en.html"
+	beginZH  = ".html _tr/div_begin_zh_CN.html"
+	endBlock = ".html _tr/div_end.html"
+)
+
+// A block is a run of lines of an article.
+type block struct {
+	lang  string // langEN, langZH, or "" for shared text
+	lines []string
+}
+
+// filterLang returns the source of an article as it reads in lang.
+//
+// For langEN and langZH, the blocks of the other language are removed,
+// except for original paragraphs that have no translation, and for
+// translations without an original. The English title of a translated
+// article, which is commented out above the Chinese one, becomes its title
+// in langEN. For langBoth, all blocks are kept and enclosed in the _tr
+// includes.
+func filterLang(src []byte, lang string) ([]byte, error) {
+	lines := strings.SplitAfter(string(src), "\n")
+	n := headerEnd(lines)
+	blocks, err := splitBlocks(lines[n:], n)
+	if err != nil {
+		return nil, err
+	}
+	if lang == langBoth && !bytes.Contains(src, []byte("\n"+markEnd)) {
+		// Only _tr blocks, if any: nothing to do.
+		return src, nil
+	}
+
+	var buf bytes.Buffer
+	header := lines[:n]
+	if lang == langEN {
+		header = englishTitle(header)
+	}
+	for _, line := range header {
+		buf.WriteString(line)
+	}
+	for i, b := range blocks {
+		switch {
+		case lang == langBoth:
+			writeBlock(&buf, b)
+			continue
+		case b.lang == "":
+		case b.lang == lang:
+		case b.lang == langEN && pairedWith(blocks[i+1:], langZH):
+			continue
+		case b.lang == langZH && pairedWith(reverse(blocks[:i]), langEN):
+			continue
+		}
+		if b.lang != "" {
+			// Keep the block apart from its neighbors, which
+			// need not be separated by blank lines.
+			buf.WriteString("\n")
+		}
+		for _, line := range b.lines {
+			buf.WriteString(line)
+		}
+		if b.lang != "" {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// headerEnd returns the number of lines in the header of an article, which
+// extends up to the first section heading or block.
+func headerEnd(lines []string) int {
+	for n, line := range lines {
+		if strings.HasPrefix(line, "*") || marker(line) != "" {
+			return n
+		}
+	}
+	return len(lines)
+}
+
+// marker returns the kind of block marker on line, in either syntax:
+// beginEN, beginZH, endBlock, or "". A #zh line is reported as markZH,
+// since it also ends the original before it, if any. Markers start at
+// column 0, so that an indented line of code reading #en stays code.
+func marker(line string) string {
+	switch m := strings.TrimRight(line, " \t\r\n"); m {
+	case beginEN, beginZH, endBlock, markZH:
+		return m
+	case markEN:
+		return beginEN
+	case markEnd:
+		return endBlock
+	}
+	return ""
+}
+
+// splitBlocks splits the body of an article, which starts at line offset,
+// into blocks. The marker lines themselves are dropped.
+func splitBlocks(lines []string, offset int) ([]block, error) {
+	var (
+		blocks []block
+		cur    block
+		start  int // line number of the current block
+	)
+	for i, line := range lines {
+		lineno := offset + i + 1
+		m := marker(line)
+		if m == markZH {
+			if cur.lang == langEN {
+				blocks = append(blocks, cur)
+				cur = block{}
+			}
+			m = beginZH
+		}
+		switch m {
+		case beginEN, beginZH:
+			if cur.lang != "" {
+				return nil, &blockError{lineno, fmt.Sprintf("block inside block started at line %d", start)}
+			}
+			blocks = append(blocks, cur)
+			cur = block{lang: langEN}
+			if m == beginZH {
+				cur.lang = langZH
+			}
+			start = lineno
+		case endBlock:
+			if cur.lang == "" {
+				return nil, &blockError{lineno, "unexpected end of block"}
+			}
+			blocks = append(blocks, cur)
+			cur = block{}
+		default:
+			cur.lines = append(cur.lines, line)
+		}
+	}
+	if cur.lang != "" {
+		return nil, &blockError{start, "unterminated block"}
+	}
+	return append(blocks, cur), nil
+}
+
+// A blockError is an error in the block markers of an article.
+type blockError struct {
+	line int
+	msg  string
+}
+
+func (e *blockError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+// writeBlock writes b to buf, enclosed in the _tr includes.
+func writeBlock(buf *bytes.Buffer, b block) {
+	begin := beginEN
+	switch b.lang {
+	case "":
+		for _, line := range b.lines {
+			buf.WriteString(line)
+		}
+		return
+	case langZH:
+		begin = beginZH
+	}
+	fmt.Fprintf(buf, "\n%s\n\n", begin)
+	for _, line := range b.lines {
+		buf.WriteString(line)
+	}
+	fmt.Fprintf(buf, "\n%s\n\n", endBlock)
+}
+
+// pairedWith reports whether the first block in blocks that is not blank
+// shared text is in lang.
+func pairedWith(blocks []block, lang string) bool {
+	for _, b := range blocks {
+		if b.lang == "" && blank(b.lines) {
+			continue
+		}
+		return b.lang == lang
+	}
+	return false
+}
+
+func reverse(blocks []block) []block {
+	r := make([]block, len(blocks))
+	for i, b := range blocks {
+		r[len(blocks)-1-i] = b
+	}
+	return r
+}
+
+func blank(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// englishTitle returns the header of an article with its title replaced by
+// the English title commented out above it, if any.
+func englishTitle(header []string) []string {
+	for i, line := range header {
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if i == 0 || !strings.HasPrefix(header[i-1], "#") {
+			break
+		}
+		title := strings.TrimSpace(strings.TrimPrefix(header[i-1], "#"))
+		if title == "" {
+			break
+		}
+		h := append([]string(nil), header...)
+		h[i] = title + "\n"
+		return h
+	}
+	return header
+}
@@ -0,0 +1,300 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const bilingualArticle = `# Package names
+包名
+4 Feb 2015
+
+Sameer Ajmani
+
+* Introduction
+
+#en
+Go code is organized into packages.
+#zh
+Go 代码以包来组织。
+#end
+
+#en
+An original without translation.
+#end
+
+	#en
+	code, with a line that reads like a marker
+
+#zh
+一段没有原文的译文。
+#end
+`
+
+const trArticle = `Package names
+4 Feb 2015
+
+* Introduction
+
+.html _tr/div_begin_en.html
+Go code is organized into packages.
+.html _tr/div_end.html
+.html _tr/div_begin_zh_CN.html
+Go 代码以包来组织。
+.html _tr/div_end.html
+
+.html _tr/div_begin_en.html
+An original without translation.
+.html _tr/div_end.html
+`
+
+var blankLines = regexp.MustCompile(`\n\n+`)
+
+// collapseBlank returns s with its runs of blank lines, which present
+// ignores, replaced by one.
+func collapseBlank(s string) string {
+	return blankLines.ReplaceAllString(strings.TrimRight(s, "\n"), "\n\n") + "\n"
+}
+
+func TestFilterLang(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, lang, out string
+	}{
+		{
+			name: "zh",
+			src:  bilingualArticle,
+			lang: langZH,
+			out: `# Package names
+包名
+4 Feb 2015
+
+Sameer Ajmani
+
+* Introduction
+
+Go 代码以包来组织。
+
+An original without translation.
+
+	#en
+	code, with a line that reads like a marker
+
+一段没有原文的译文。
+`,
+		},
+		{
+			name: "en",
+			src:  bilingualArticle,
+			lang: langEN,
+			out: `# Package names
+Package names
+4 Feb 2015
+
+Sameer Ajmani
+
+* Introduction
+
+Go code is organized into packages.
+
+An original without translation.
+
+	#en
+	code, with a line that reads like a marker
+
+一段没有原文的译文。
+`,
+		},
+		{
+			name: "both",
+			src:  bilingualArticle,
+			lang: langBoth,
+			out: `# Package names
+包名
+4 Feb 2015
+
+Sameer Ajmani
+
+* Introduction
+
+.html _tr/div_begin_en.html
+
+Go code is organized into packages.
+
+.html _tr/div_end.html
+
+.html _tr/div_begin_zh_CN.html
+
+Go 代码以包来组织。
+
+.html _tr/div_end.html
+
+.html _tr/div_begin_en.html
+
+An original without translation.
+
+.html _tr/div_end.html
+
+	#en
+	code, with a line that reads like a marker
+
+.html _tr/div_begin_zh_CN.html
+
+一段没有原文的译文。
+
+.html _tr/div_end.html
+`,
+		},
+		{
+			name: "tr zh",
+			src:  trArticle,
+			lang: langZH,
+			out: `Package names
+4 Feb 2015
+
+* Introduction
+
+Go 代码以包来组织。
+
+An original without translation.
+`,
+		},
+		{
+			name: "tr en",
+			src:  trArticle,
+			lang: langEN,
+			out: `Package names
+4 Feb 2015
+
+* Introduction
+
+Go code is organized into packages.
+
+An original without translation.
+`,
+		},
+		{
+			name: "tr both",
+			src:  trArticle,
+			lang: langBoth,
+			out:  trArticle,
+		},
+		{
+			name: "untranslated",
+			src:  "Package names\n4 Feb 2015\n\n* Introduction\n\nGo code is organized into packages.\n",
+			lang: langZH,
+			out:  "Package names\n4 Feb 2015\n\n* Introduction\n\nGo code is organized into packages.\n",
+		},
+	} {
+		out, err := filterLang([]byte(tt.src), tt.lang)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got, want := collapseBlank(string(out)), collapseBlank(tt.out); got != want {
+			t.Errorf("%s: filterLang =\n%s\nwant:\n%s", tt.name, got, want)
+		}
+	}
+}
+
+func TestFilterLangErrors(t *testing.T) {
+	for _, tt := range []struct {
+		src, err string
+	}{
+		{"T\n\n* S\n\n#en\na\n#en\nb\n#end\n", "line 7: block inside block started at line 5"},
+		{"T\n\n* S\n\n#zh\na\n#zh\nb\n#end\n", "line 7: block inside block started at line 5"},
+		{"T\n\n* S\n\n.html _tr/div_begin_zh_CN.html\na\n#en\nb\n#end\n", "line 7: block inside block started at line 5"},
+		{"T\n\n* S\n\n#en\na\n", "line 5: unterminated block"},
+		{"T\n\n* S\n\n#en\na\n#zh\nb\n", "line 7: unterminated block"},
+		{"T\n\n* S\n\na\n#end\n", "line 6: unexpected end of block"},
+		{"T\n\n* S\n\n.html _tr/div_begin_en.html\na\n.html _tr/div_end.html\n.html _tr/div_end.html\n", "line 8: unexpected end of block"},
+	} {
+		for _, lang := range langs {
+			_, err := filterLang([]byte(tt.src), lang)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("filterLang(%q, %s) error = %v; want %s", tt.src, lang, err, tt.err)
+			}
+		}
+	}
+}
+
+func TestMarker(t *testing.T) {
+	for _, tt := range []struct {
+		line, marker string
+	}{
+		{"#en\n", beginEN},
+		{"#zh\n", markZH},
+		{"#end \r\n", endBlock},
+		{".html _tr/div_begin_zh_CN.html\n", beginZH},
+		{"\t#en\n", ""},
+		{" #end\n", ""},
+		{"#english\n", ""},
+		{"# Package names\n", ""},
+	} {
+		if m := marker(tt.line); m != tt.marker {
+			t.Errorf("marker(%q) = %q; want %q", tt.line, m, tt.marker)
+		}
+	}
+}
+
+func TestLangURLs(t *testing.T) {
+	for _, tt := range []struct {
+		url  string
+		urls map[string]string
+	}{
+		{"/package-names", map[string]string{
+			langZH:   "/package-names?lang=zh",
+			langEN:   "/package-names?lang=en",
+			langBoth: "/package-names?lang=both",
+		}},
+		{"/search?q=%E5%B9%B6%E5%8F%91&lang=en", map[string]string{
+			langZH:   "/search?lang=zh&q=%E5%B9%B6%E5%8F%91",
+			langEN:   "/search?lang=en&q=%E5%B9%B6%E5%8F%91",
+			langBoth: "/search?lang=both&q=%E5%B9%B6%E5%8F%91",
+		}},
+	} {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if urls := langURLs(u); !reflect.DeepEqual(urls, tt.urls) {
+			t.Errorf("langURLs(%s) = %v; want %v", tt.url, urls, tt.urls)
+		}
+	}
+}
+
+func TestRequestLang(t *testing.T) {
+	for _, tt := range []struct {
+		url, cookie string
+		lang        string
+		set         bool // whether the cookie is set
+	}{
+		{"/", "", defaultLang, false},
+		{"/", langEN, langEN, false},
+		{"/", "fr", defaultLang, false},
+		{"/?lang=both", "", langBoth, true},
+		{"/?lang=zh", langEN, langZH, true},
+		{"/?lang=fr", langEN, langEN, false},
+	} {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: langCookie, Value: tt.cookie})
+		}
+		w := httptest.NewRecorder()
+		if lang := requestLang(w, r); lang != tt.lang {
+			t.Errorf("%s with cookie %q: lang %q; want %q", tt.url, tt.cookie, lang, tt.lang)
+		}
+		set := w.Header().Get("Set-Cookie")
+		if tt.set != (set != "") || tt.set && !strings.HasPrefix(set, langCookie+"="+tt.lang+";") {
+			t.Errorf("%s with cookie %q: Set-Cookie %q", tt.url, tt.cookie, set)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2013 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+var (
+	httpAddr   = flag.String("http", defaultAddr(), "HTTP listen `address`; the default is :$PORT if PORT is set")
+	configPath = flag.String("config", "", "read settings from this JSON `file`, overriding the built-in config.json")
+	dir        = flag.String("dir", "", "serve the content, template and static files in this `directory` instead of the built-in ones")
+	reload     = flag.Bool("reload", false, "with -dir, reload content, templates and static files when they change")
+	exportDir  = flag.String("export", "", "export the blog as static files to this directory and exit")
+	check      = flag.Bool("check", false, "check the articles, report problems and exit")
+	bookFile   = flag.String("book", "", "write the articles of -tag and those named by the arguments to this EPUB `file`, or printable HTML file if it ends in .html, and exit")
+	bookLang   = flag.String("booklang", langZH, "`language` of -book: zh, the translation, or both, side by side with the original")
+	bookTag    = flag.String("tag", "", "with -book, write the articles with this `tag`")
+	health     = flag.Bool("health", false, "serve /healthz, and /readyz, which fails while the articles have problems or the articles or templates fail to reload")
+	metricsOn  = flag.Bool("metrics", false, "serve Prometheus metrics of the requests and reloads at /metrics")
+	accessLog  = flag.String("accesslog", "", "log each request as a line of JSON to this `file`; - is the standard error")
+	drafts     = flag.Bool("drafts", false, "publish drafts and scheduled articles, as on a staging server")
+	playURL    = flag.String("play", "https://play.golang.org", "URL of the playground backend running the .play snippets, such as one served by cmd/playground; empty disables running them")
+)
+
+// shutdownTimeout is how long the server waits for the requests in
+// progress when it is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+// defaultAddr returns the default listen address: that of the port given
+// by the environment, as on hosting services, or a local one.
+func defaultAddr() string {
+	if port := os.Getenv("PORT"); port != "" {
+		return ":" + port
+	}
+	return "localhost:8080"
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: blog [flags]\n")
+	fmt.Fprintf(os.Stderr, "       blog -book file [-booklang lang] [-tag tag] [slug ...]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetPrefix("blog: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 0 && *bookFile == "" {
+		usage()
+	}
+	if *reload && *dir == "" {
+		log.Fatal("-reload needs -dir")
+	}
+	config, err := readConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	content, templates, staticFiles, err := files(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.Content = content
+	config.Templates = templates
+	config.Static = staticFiles
+	config.PlayEnabled = *playURL != ""
+	config.Drafts = *drafts
+	if *metricsOn {
+		config.Metrics = newMetrics()
+	}
+
+	diags, err := checkContent(content)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *check {
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+		if len(diags) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if len(diags) > 0 && !*reload {
+		// With -reload, the reloader logs each of them.
+		log.Printf("%d problems in articles; run with -check to list them", len(diags))
+	}
+	if *exportDir != "" {
+		s, err := NewServer(config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := s.export(*exportDir); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *bookFile != "" {
+		s, err := NewServer(config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := s.writeBook(*bookFile, *bookLang, *bookTag, flag.Args()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	mux := http.NewServeMux()
+	ready := func() error { return problemsError(diags) }
+	if *reload {
+		rl := newReloader(config, nil, nil, filepath.Join(*dir, "content"), filepath.Join(*dir, "template"), filepath.Join(*dir, "static"))
+		mux.Handle("/", rl)
+		ready = rl.ready
+	} else {
+		s, err := NewServer(config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if s.nextPublish.IsZero() {
+			mux.Handle("/", s)
+		} else {
+			// Rebuild the server to publish the scheduled articles.
+			rl := newReloader(config, s, diags)
+			mux.Handle("/", rl)
+			ready = rl.ready
+		}
+	}
+	if *playURL != "" {
+		u, err := url.Parse(*playURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p := httputil.NewSingleHostReverseProxy(u)
+		director := p.Director
+		p.Director = func(r *http.Request) {
+			director(r)
+			r.Host = u.Host
+		}
+		mux.Handle("/compile", p)
+		mux.Handle("/fmt", p)
+	}
+
+	if *health {
+		mux.HandleFunc("/healthz", healthz)
+		mux.Handle("/readyz", readyz(ready))
+	}
+	if config.Metrics != nil {
+		mux.Handle("/metrics", config.Metrics)
+	}
+	var h http.Handler = mux
+	if config.Metrics != nil || *accessLog != "" {
+		var w io.Writer
+		switch *accessLog {
+		case "":
+		case "-":
+			w = os.Stderr
+		default:
+			f, err := os.OpenFile(*accessLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		h = instrument(mux, config.BasePath, config.Metrics, w)
+	}
+
+	srv := &http.Server{Addr: *httpAddr, Handler: h}
+	done := make(chan bool)
+	go func() {
+		// Let the requests in progress finish before exiting.
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		<-c
+		log.Print("shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Print(err)
+		}
+		close(done)
+	}()
+	log.Printf("serving on %s", *httpAddr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-done
+}
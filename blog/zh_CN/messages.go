@@ -0,0 +1,130 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// The text of the pages around the articles, such as their menus and
+// headings, is looked up in a message catalog by the msg function of the
+// templates:
+//
+//	<h2>{{msg "article.related"}}</h2>
+//	<p>{{msg "tag.count" (len .Data.Docs)}}</p>
+//
+// The messages of a locale are in messages/<locale>.txt in the template
+// directory, with one message per line: its key, followed by its text. The
+// text is HTML, in which the arguments of msg are formatted by the verbs of
+// package fmt and escaped; translations may reorder them with explicit
+// argument indexes, as in %[2]d. The messages missing from a locale are
+// those of fallbackLocale. The msgextract command lists the messages that a
+// locale lacks.
+const (
+	messageDir     = "messages"
+	fallbackLocale = "en"
+)
+
+// A catalog holds the messages of a locale, and those of fallbackLocale.
+type catalog struct {
+	msgs     map[string]string
+	fallback map[string]string
+}
+
+// readCatalog reads the catalog of locale from the messages directory of
+// the template files fsys.
+func readCatalog(fsys fs.FS, locale string) (*catalog, error) {
+	fallback, err := readMessages(fsys, fallbackLocale)
+	if err != nil {
+		return nil, err
+	}
+	c := &catalog{msgs: fallback, fallback: fallback}
+	if locale != "" && locale != fallbackLocale {
+		c.msgs, err = readMessages(fsys, locale)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// readMessages reads the messages of locale.
+func readMessages(fsys fs.FS, locale string) (map[string]string, error) {
+	file := path.Join(messageDir, locale+".txt")
+	f, err := fsys.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no messages for locale %s: %v", locale, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseMessages(f, file)
+}
+
+// parseMessages parses messages read from r. Blank lines and lines
+// starting with # are ignored.
+func parseMessages(r io.Reader, file string) (map[string]string, error) {
+	msgs := make(map[string]string)
+	lines := make(map[string]int)
+	s := bufio.NewScanner(r)
+	for lineno := 1; s.Scan(); lineno++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: want key and text", file, lineno)
+		}
+		key, text := line[:i], strings.TrimSpace(line[i:])
+		if prev, ok := lines[key]; ok {
+			return nil, fmt.Errorf("%s:%d: message %s already defined at line %d", file, lineno, key, prev)
+		}
+		lines[key] = lineno
+		msgs[key] = text
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return msgs, nil
+}
+
+// msg returns the message key, formatted with args. A key without a
+// message is returned as is, which shows it on the page.
+func (c *catalog) msg(key string, args ...interface{}) template.HTML {
+	text, ok := c.msgs[key]
+	if !ok {
+		text, ok = c.fallback[key]
+	}
+	if !ok {
+		return template.HTML(template.HTMLEscapeString(key))
+	}
+	if len(args) == 0 {
+		return template.HTML(text)
+	}
+	for i, arg := range args {
+		if _, ok := arg.(template.HTML); !ok {
+			args[i] = escaper{arg}
+		}
+	}
+	return template.HTML(fmt.Sprintf(text, args...))
+}
+
+// An escaper formats a value as fmt does, escaped for HTML.
+type escaper struct {
+	v interface{}
+}
+
+func (e escaper) Format(f fmt.State, verb rune) {
+	io.WriteString(f, template.HTMLEscapeString(fmt.Sprintf(fmt.FormatString(f, verb), e.v)))
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const templateDir = "template"
+
+var msgCall = regexp.MustCompile(`msg "([^"]+)"`)
+
+// TestMessageCatalogs checks that the catalogs of all locales have the
+// messages used by the templates, and no others.
+func TestMessageCatalogs(t *testing.T) {
+	fsys := os.DirFS(templateDir)
+	en, err := readMessages(fsys, fallbackLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := make(map[string]bool)
+	files, err := filepath.Glob(filepath.Join(templateDir, "*.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range append(files, "export.go") {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range msgCall.FindAllStringSubmatch(string(data), -1) {
+			used[m[1]] = true
+		}
+	}
+	for s := untranslated; s <= complete; s++ {
+		used["status."+s.String()] = true
+	}
+	for key := range used {
+		if _, ok := en[key]; !ok {
+			t.Errorf("message %s is not in %s.txt", key, fallbackLocale)
+		}
+	}
+	for key := range en {
+		if !used[key] {
+			t.Errorf("message %s of %s.txt is not used", key, fallbackLocale)
+		}
+	}
+
+	locales, err := filepath.Glob(filepath.Join(templateDir, messageDir, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range locales {
+		locale := strings.TrimSuffix(filepath.Base(file), ".txt")
+		msgs, err := readMessages(fsys, locale)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for key := range msgs {
+			if _, ok := en[key]; !ok {
+				t.Errorf("message %s of %s.txt is not in %s.txt", key, locale, fallbackLocale)
+			}
+		}
+	}
+}
+
+func TestMsg(t *testing.T) {
+	c := &catalog{
+		msgs: map[string]string{
+			"found": "共找到 %d 篇与 “%s” 相关的文章.",
+			"more":  `查看 <a href="%s/index">文章索引</a>.`,
+		},
+		fallback: map[string]string{
+			"found": "Articles matching “%[2]s”: %[1]d.",
+			"month": "%[2]v %[1]d",
+		},
+	}
+	for _, tt := range []struct {
+		key  string
+		args []interface{}
+		out  template.HTML
+	}{
+		{"found", []interface{}{2, "<b>并发</b>"}, "共找到 2 篇与 “&lt;b&gt;并发&lt;/b&gt;” 相关的文章."},
+		{"more", []interface{}{"/blog"}, `查看 <a href="/blog/index">文章索引</a>.`},
+		{"more", []interface{}{`"><script>`}, `查看 <a href="&#34;&gt;&lt;script&gt;/index">文章索引</a>.`},
+		{"month", []interface{}{2013, time.May}, "May 2013"},
+		{"nosuch", nil, "nosuch"},
+	} {
+		if out := c.msg(tt.key, tt.args...); out != tt.out {
+			t.Errorf("msg(%q, %v) = %q; want %q", tt.key, tt.args, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the buckets of the
+// request latency histograms.
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// metrics counts the requests of the server and the failures of its
+// reloads. It serves them in the text format of Prometheus. The methods of
+// a nil *metrics do nothing, so that the server need not check whether
+// metrics are enabled.
+type metrics struct {
+	mu             sync.Mutex
+	requests       map[requestKey]uint64
+	latency        map[string]*histogram // by route
+	legacyMisses   uint64
+	reloadFailures uint64
+}
+
+type requestKey struct {
+	route string
+	code  int
+}
+
+// A histogram counts observations in latencyBuckets.
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests: make(map[requestKey]uint64),
+		latency:  make(map[string]*histogram),
+	}
+}
+
+// request records a request to route answered with code in d.
+func (m *metrics) request(route string, code int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{route, code}]++
+	h, ok := m.latency[route]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		m.latency[route] = h
+	}
+	sec := d.Seconds()
+	for i, le := range latencyBuckets {
+		if sec <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += sec
+}
+
+// legacyMiss records a request for a legacy path, one of the redirect table
+// or of the form of the old blog, that was not found.
+func (m *metrics) legacyMiss() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.legacyMisses++
+	m.mu.Unlock()
+}
+
+// reloadFailed records a failed reload of the server.
+func (m *metrics) reloadFailed() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.reloadFailures++
+	m.mu.Unlock()
+}
+
+// ServeHTTP serves the metrics in the text format of Prometheus.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	m.write(&buf)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// write writes the metrics to buf, sorted by labels.
+func (m *metrics) write(buf *bytes.Buffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(buf, "# HELP blog_requests_total Requests served, by route and status code.\n")
+	fmt.Fprintf(buf, "# TYPE blog_requests_total counter\n")
+	keys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, k := range keys {
+		fmt.Fprintf(buf, "blog_requests_total{route=%q,code=\"%d\"} %d\n", k.route, k.code, m.requests[k])
+	}
+
+	fmt.Fprintf(buf, "# HELP blog_request_duration_seconds Time taken to serve requests, by route.\n")
+	fmt.Fprintf(buf, "# TYPE blog_request_duration_seconds histogram\n")
+	routes := make([]string, 0, len(m.latency))
+	for route := range m.latency {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		h := m.latency[route]
+		var n uint64
+		for i, le := range latencyBuckets {
+			n += h.counts[i]
+			fmt.Fprintf(buf, "blog_request_duration_seconds_bucket{route=%q,le=%q} %d\n", route, strconv.FormatFloat(le, 'g', -1, 64), n)
+		}
+		fmt.Fprintf(buf, "blog_request_duration_seconds_bucket{route=%q,le=\"+Inf\"} %d\n", route, h.count)
+		fmt.Fprintf(buf, "blog_request_duration_seconds_sum{route=%q} %g\n", route, h.sum)
+		fmt.Fprintf(buf, "blog_request_duration_seconds_count{route=%q} %d\n", route, h.count)
+	}
+
+	fmt.Fprintf(buf, "# HELP blog_legacy_misses_total Requests for legacy paths of the old blog that were not found.\n")
+	fmt.Fprintf(buf, "# TYPE blog_legacy_misses_total counter\n")
+	fmt.Fprintf(buf, "blog_legacy_misses_total %d\n", m.legacyMisses)
+
+	fmt.Fprintf(buf, "# HELP blog_reload_failures_total Failed reloads of the articles and templates.\n")
+	fmt.Fprintf(buf, "# TYPE blog_reload_failures_total counter\n")
+	fmt.Fprintf(buf, "blog_reload_failures_total %d\n", m.reloadFailures)
+}
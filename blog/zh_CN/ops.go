@@ -0,0 +1,152 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthz answers that the server is up.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// readyz returns a handler that answers whether the server is ready to
+// serve the blog: whether ready returns nil.
+func readyz(ready func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+// problemsError returns an error listing the problems in the articles
+// diags, or nil if there are none.
+func problemsError(diags []string) error {
+	if len(diags) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d problems in articles:\n%s", len(diags), strings.Join(diags, "\n"))
+}
+
+// route returns the kind of the page at path, for the metrics and the
+// access log, which need few distinct values. Articles, the content files
+// and the paths that are not found are all pages.
+func route(basePath, path string) string {
+	switch {
+	case strings.HasPrefix(path, "/lib/godoc/"), strings.HasPrefix(path, "/static/"), path == "/favicon.ico":
+		return "static"
+	case path == "/healthz", path == "/readyz", path == "/metrics":
+		return "ops"
+	case path == "/compile", path == "/fmt":
+		return "play"
+	}
+	p := strings.TrimPrefix(path, basePath)
+	switch {
+	case p == "/":
+		return "home"
+	case p == "/index", p == "/translations", p == "/tags", p == "/search", p == "/sitemap.xml":
+		return p[1:]
+	case p == "/feed.atom", p == "/.json", p == "/translated.atom", p == "/translated.json", strings.HasPrefix(p, "/feeds/"):
+		return "feed"
+	case strings.HasPrefix(p, "/tag/"):
+		return "tag"
+	case p == "/archive", strings.HasPrefix(p, "/archive/"):
+		return "archive"
+	}
+	return "page"
+}
+
+// An accessEntry is a line of the access log.
+type accessEntry struct {
+	Time      time.Time `json:"time"`
+	Remote    string    `json:"remote"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Route     string    `json:"route"`
+	Status    int       `json:"status"`
+	Bytes     int64     `json:"bytes"`
+	Duration  float64   `json:"duration"` // seconds
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+// A statusWriter records the status and size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+	n    int64
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// Unwrap returns the ResponseWriter, for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// instrument returns a handler that serves the requests with h, recording
+// them in m, if set, and logging each as a line of JSON to accessLog, if
+// set.
+func instrument(h http.Handler, basePath string, m *metrics, accessLog io.Writer) http.Handler {
+	var mu sync.Mutex // serializes the lines of the access log
+	var enc *json.Encoder
+	if accessLog != nil {
+		enc = json.NewEncoder(accessLog)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r)
+		d := time.Since(start)
+		if sw.code == 0 {
+			sw.code = http.StatusOK
+		}
+		rt := route(basePath, r.URL.Path)
+		m.request(rt, sw.code, d)
+		if enc == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(accessEntry{
+			Time:      start.UTC(),
+			Remote:    r.RemoteAddr,
+			Method:    r.Method,
+			URI:       r.RequestURI,
+			Route:     rt,
+			Status:    sw.code,
+			Bytes:     sw.n,
+			Duration:  d.Seconds(),
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+		})
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoute(t *testing.T) {
+	for _, tt := range []struct {
+		basePath, path, route string
+	}{
+		{"", "/", "home"},
+		{"", "/index", "index"},
+		{"/blog", "/blog/index", "index"},
+		{"", "/tag/concurrency", "tag"},
+		{"", "/tag/concurrency.atom", "tag"},
+		{"", "/feeds/posts/default", "feed"},
+		{"", "/archive/2013/05", "archive"},
+		{"", "/lib/godoc/style.css", "static"},
+		{"/blog", "/static/favicon.ico", "static"},
+		{"", "/metrics", "ops"},
+		{"", "/compile", "play"},
+		{"", "/package-names", "page"},
+		{"", "/no/such/page", "page"},
+	} {
+		if r := route(tt.basePath, tt.path); r != tt.route {
+			t.Errorf("route(%q, %q) = %q; want %q", tt.basePath, tt.path, r, tt.route)
+		}
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	m := newMetrics()
+	var log bytes.Buffer
+	h := instrument(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("home"))
+	}), "", m, &log)
+	for _, p := range []string{"/", "/", "/nosuch"} {
+		r := httptest.NewRequest("GET", p, nil)
+		r.Header.Set("User-Agent", "test")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	m.legacyMiss()
+	m.reloadFailed()
+
+	var buf bytes.Buffer
+	m.write(&buf)
+	for _, want := range []string{
+		`blog_requests_total{route="home",code="200"} 2`,
+		`blog_requests_total{route="page",code="404"} 1`,
+		`blog_request_duration_seconds_bucket{route="home",le="+Inf"} 2`,
+		`blog_request_duration_seconds_count{route="page"} 1`,
+		`blog_legacy_misses_total 1`,
+		`blog_reload_failures_total 1`,
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("metrics have no line %s:\n%s", want, buf.String())
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("access log has %d lines; want 3:\n%s", len(lines), log.String())
+	}
+	var e accessEntry
+	if err := json.Unmarshal([]byte(lines[2]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.URI != "/nosuch" || e.Route != "page" || e.Status != http.StatusNotFound || e.Bytes == 0 || e.UserAgent != "test" {
+		t.Errorf("access log entry = %+v", e)
+	}
+}
+
+func TestMetricsNil(t *testing.T) {
+	var m *metrics
+	m.request("home", http.StatusOK, time.Second)
+	m.legacyMiss()
+	m.reloadFailed()
+}
+
+func TestReadyz(t *testing.T) {
+	var err error
+	h := readyz(func() error { return err })
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("ready: status %d; want %d", w.Code, http.StatusOK)
+	}
+	err = errors.New("tags.txt:3: want tag and label")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "tags.txt:3") {
+		t.Errorf("not ready: status %d, body %q; want %d and the error", w.Code, w.Body.String(), http.StatusServiceUnavailable)
+	}
+}
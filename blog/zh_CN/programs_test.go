@@ -0,0 +1,268 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const supportDir = "support"
+
+// What the articles say a program does.
+const (
+	runs    = iota // builds, and runs successfully
+	noBuild        // does not build: a fragment, or a compile error the article shows
+	race           // the race detector reports a data race
+	panics         // builds, and panics when run
+	noRace         // runs successfully with the race detector
+	passes         // is a package whose test passes
+	builds         // builds, and is not run: a package, or a server that never exits
+	skip           // is not built; why says why
+)
+
+// A program is what an article says about a program, by its path.
+type program struct {
+	want   int
+	args   []string // arguments to run it with
+	output string   // text its build, run or test output must contain
+	why    string
+}
+
+// programs holds the programs that do not just build and run successfully.
+var programs = map[string]program{
+	"content/constants/bool.go":            {want: noBuild, output: "cannot use"},
+	"content/constants/complex1.go":        {want: noBuild, output: "cannot use"},
+	"content/constants/exercise1.go":       {want: noBuild, output: "overflows"},
+	"content/constants/exercise2.go":       {want: noBuild, output: "overflows"},
+	"content/constants/exercise4.go":       {want: noBuild, output: "overflows"},
+	"content/constants/exercise5.go":       {want: noBuild, output: "overflows"},
+	"content/constants/float1.go":          {want: noBuild, output: "cannot use"},
+	"content/constants/float2.go":          {want: noBuild, output: "cannot use"},
+	"content/constants/float3.go":          {want: noBuild, output: "overflows"},
+	"content/constants/int1.go":            {want: noBuild, output: "cannot use"},
+	"content/constants/int2.go":            {want: noBuild, output: "overflows"},
+	"content/constants/int3.go":            {want: noBuild, output: "overflows"},
+	"content/constants/int4.go":            {want: noBuild, output: "overflows"},
+	"content/constants/string2.go":         {want: noBuild, output: "cannot use"},
+	"content/constants/syntax.go":          {output: "float64 0"},
+	"content/context/interface.go":         {want: noBuild},
+	"content/context/google/google.go":     {want: builds},
+	"content/context/server/server.go":     {want: builds},
+	"content/context/userip/userip.go":     {want: builds},
+	"content/cover/pkg.go":                 {want: passes, output: "coverage: 42.9% of statements"},
+	"content/cover/pkg_test.go":            {want: skip, why: "the test of cover/pkg.go"},
+	"content/first-go-program/slist.go":    {want: noBuild, output: "syntax error"},
+	"content/pipelines/bounded.go":         {args: []string{"."}, output: "bounded.go"},
+	"content/pipelines/parallel.go":        {args: []string{"."}, output: "parallel.go"},
+	"content/pipelines/serial.go":          {args: []string{"."}, output: "serial.go"},
+	"content/playground/os.go":             {output: "Hello, file system"},
+	"content/race-detector/blackhole.go":   {want: noBuild},
+	"content/race-detector/timer.go":       {want: race},
+	"content/race-detector/timer-fixed.go": {want: noRace},
+	"content/slices/prog050.go":            {output: "/USR/BIN/TSO"},
+	"content/slices/prog060.go":            {want: panics, output: "slice bounds out of range"},
+	"support/racy":                         {want: race},
+}
+
+// findPrograms returns the Go files that the articles show with .code and
+// .play, and the directories of the programs in the support directory.
+func findPrograms() ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	articles, err := filepath.Glob(filepath.Join(contentDir, "*.article"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range articles {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		s := bufio.NewScanner(bytes.NewReader(data))
+		for s.Scan() {
+			f := strings.Fields(s.Text())
+			if len(f) == 0 || f[0] != ".code" && f[0] != ".play" {
+				continue
+			}
+			for _, arg := range f[1:] {
+				if strings.HasPrefix(arg, "-") {
+					continue
+				}
+				p := contentDir + "/" + arg
+				if strings.HasSuffix(arg, ".go") && !seen[p] {
+					seen[p] = true
+					paths = append(paths, p)
+				}
+				break
+			}
+		}
+	}
+	dirs, err := ioutil.ReadDir(supportDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dirs {
+		if d.IsDir() {
+			paths = append(paths, supportDir+"/"+d.Name())
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// sources returns the Go files of the program at path: the file itself, or
+// those of the directory but its tests.
+func sources(path string) ([]string, error) {
+	if strings.HasSuffix(path, ".go") {
+		return []string{filepath.FromSlash(path)}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(filepath.FromSlash(path), "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var srcs []string
+	for _, f := range files {
+		if !strings.HasSuffix(f, "_test.go") {
+			srcs = append(srcs, f)
+		}
+	}
+	return srcs, nil
+}
+
+// TestPrograms builds and runs the programs of the articles, and checks
+// that they still do what the articles say, such as fail to compile or be
+// caught by the race detector.
+func TestPrograms(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the programs of the articles")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	paths, err := findPrograms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]bool)
+	for _, p := range paths {
+		found[p] = true
+	}
+	for p := range programs {
+		if !found[p] {
+			t.Errorf("programs has %s, which is neither shown by an article nor in %s", p, supportDir)
+		}
+	}
+	cgo := cgoEnabled(goTool)
+	dir, err := ioutil.TempDir("", "programs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("group", func(t *testing.T) {
+		for i, p := range paths {
+			i, p := i, p
+			t.Run(p, func(t *testing.T) {
+				t.Parallel()
+				testProgram(t, goTool, cgo, p, filepath.Join(dir, fmt.Sprint("prog", i)))
+			})
+		}
+	})
+}
+
+// cgoEnabled reports whether the go command builds with cgo, which the race
+// detector needs: CGO_ENABLED=0 in the environment, or no C compiler, turns
+// it off.
+func cgoEnabled(goTool string) bool {
+	out, err := exec.Command(goTool, "env", "CGO_ENABLED").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "1"
+}
+
+// testProgram checks that the program at path does what programs says,
+// writing its binary to bin. Without cgo, it skips the programs to run with
+// the race detector.
+func testProgram(t *testing.T, goTool string, cgo bool, path, bin string) {
+	prog := programs[path]
+	if prog.want == skip {
+		t.Skip(prog.why)
+	}
+	if (prog.want == race || prog.want == noRace) && !cgo {
+		t.Skip("the race detector needs cgo")
+	}
+	srcs, err := sources(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if prog.want == passes {
+		test := strings.TrimSuffix(srcs[0], ".go") + "_test.go"
+		out, err := exec.CommandContext(ctx, goTool, append([]string{"test", "-cover"}, append(srcs, test)...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("go test: %v\n%s", err, out)
+		}
+		checkOutput(t, prog, out)
+		return
+	}
+
+	args := []string{"build", "-o", bin}
+	if prog.want == race || prog.want == noRace {
+		args = append(args, "-race")
+	}
+	out, err := exec.CommandContext(ctx, goTool, append(args, srcs...)...).CombinedOutput()
+	if prog.want == noBuild {
+		if err == nil {
+			t.Fatalf("built; the article says it does not build")
+		}
+		checkOutput(t, prog, out)
+		return
+	}
+	if err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	if prog.want == builds {
+		return
+	}
+
+	cmd := exec.CommandContext(ctx, bin, prog.args...)
+	cmd.Dir = filepath.Dir(srcs[0])
+	out, err = cmd.CombinedOutput()
+	reported := bytes.Contains(out, []byte("WARNING: DATA RACE"))
+	switch {
+	case prog.want == race:
+		if !reported {
+			t.Fatalf("the race detector reported no race (%v):\n%s", err, out)
+		}
+		return
+	case prog.want == panics:
+		if err == nil || !bytes.Contains(out, []byte("panic: ")) {
+			t.Fatalf("did not panic (%v):\n%s", err, out)
+		}
+	case reported:
+		t.Fatalf("the race detector reported a race:\n%s", out)
+	case err != nil:
+		t.Fatalf("%v\n%s", err, out)
+	}
+	checkOutput(t, prog, out)
+}
+
+func checkOutput(t *testing.T, prog program, out []byte) {
+	if !bytes.Contains(out, []byte(prog.output)) {
+		t.Errorf("output has no %q:\n%s", prog.output, out)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// An article that is not ready to be read is marked with lines in its
+// header, next to its date and tags:
+//
+//	Draft: 正在校对
+//	Publish: 2016-05-01 08:00 +0800
+//
+// A draft is not published until its Draft line is removed; the text after
+// Draft: is a note for the translators. An article is published at the time
+// of its Publish line, if any, or else at its date. Until then, it is left
+// out of the pages, feeds and search results, and its page is only served
+// to readers with the preview token, or by a server run with -drafts.
+const (
+	draftPrefix   = "Draft:"
+	publishPrefix = "Publish:"
+)
+
+// publishLayouts are the layouts of the time of a Publish line, which is in
+// UTC unless it has an offset.
+var publishLayouts = []string{"2006-01-02 15:04 -0700", "2006-01-02 15:04", "2006-01-02"}
+
+// parsePublish parses the time of a Publish line.
+func parsePublish(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range publishLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("publication time %q is not YYYY-MM-DD [HH:MM [-0700]]", s)
+}
+
+// A publication tells when an article is published.
+type publication struct {
+	draft   bool
+	publish time.Time // time of the Publish line, or zero
+}
+
+// readPublication returns the publication of the article src, read from
+// the first paragraph of its header, and src with the lines it was read
+// from commented out, since present does not know them.
+func readPublication(src []byte) (publication, []byte, error) {
+	var pub publication
+	lines := strings.SplitAfter(string(src), "\n")
+	n := headerEnd(lines)
+	found := false
+	for i := 1; i < n && strings.TrimSpace(lines[i]) != ""; i++ {
+		switch line := lines[i]; {
+		case strings.HasPrefix(line, draftPrefix):
+			pub.draft = true
+		case strings.HasPrefix(line, publishPrefix):
+			t, err := parsePublish(line[len(publishPrefix):])
+			if err != nil {
+				return pub, nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			pub.publish = t
+		default:
+			continue
+		}
+		lines[i] = "#" + lines[i]
+		found = true
+	}
+	if !found {
+		return pub, src, nil
+	}
+	return pub, []byte(strings.Join(lines, "")), nil
+}
+
+// scheduled returns the time at which an article dated date is published,
+// if it is after now, or else the zero time.
+func (pub publication) scheduled(date, now time.Time) time.Time {
+	t := date
+	if !pub.publish.IsZero() {
+		t = pub.publish
+	}
+	if t.After(now) {
+		return t
+	}
+	return time.Time{}
+}
+
+// Unpublished reports whether d is a draft or scheduled to be published.
+func (d *Doc) Unpublished() bool {
+	return d.Draft || !d.Scheduled.IsZero()
+}
+
+// previewCookie remembers the preview token of a reader, so that the links
+// of a previewed article, such as those of the language switch, keep
+// showing unpublished articles.
+const previewCookie = "preview"
+
+// preview reports whether the request r may see unpublished articles: it
+// has the preview token in its preview parameter, which is then
+// remembered, or in its cookie.
+func (s *Server) preview(w http.ResponseWriter, r *http.Request) bool {
+	token := s.cfg.PreviewToken
+	if token == "" {
+		return false
+	}
+	match := func(t string) bool {
+		return subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1
+	}
+	if match(r.FormValue("preview")) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     previewCookie,
+			Value:    token,
+			Path:     "/",
+			HttpOnly: true,
+		})
+		return true
+	}
+	c, err := r.Cookie(previewCookie)
+	return err == nil && match(c.Value)
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadPublication(t *testing.T) {
+	date := time.Date(2015, 5, 4, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		src       string
+		out       string
+		draft     bool
+		scheduled time.Time
+	}{
+		{
+			src: "Go slices\n4 May 2015\n\nRob Pike\n\n* Section\n",
+			out: "Go slices\n4 May 2015\n\nRob Pike\n\n* Section\n",
+		},
+		{
+			src:   "Go slices\n4 May 2015\nDraft: 校对中\n\nRob Pike\n",
+			out:   "Go slices\n4 May 2015\n#Draft: 校对中\n\nRob Pike\n",
+			draft: true,
+		},
+		{
+			src:       "Go slices\n4 May 2015\nPublish: 2016-05-01 08:00 +0800\n\nRob Pike\n",
+			out:       "Go slices\n4 May 2015\n#Publish: 2016-05-01 08:00 +0800\n\nRob Pike\n",
+			scheduled: time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			src: "Go slices\n4 May 2015\nPublish: 2016-03-01\n\nRob Pike\n",
+			out: "Go slices\n4 May 2015\n#Publish: 2016-03-01\n\nRob Pike\n",
+		},
+		{
+			// Only the first paragraph of the header counts.
+			src: "Go slices\n4 May 2015\n\nDraft: the author\n",
+			out: "Go slices\n4 May 2015\n\nDraft: the author\n",
+		},
+	} {
+		pub, out, err := readPublication([]byte(tt.src))
+		if err != nil {
+			t.Errorf("readPublication(%q): %v", tt.src, err)
+			continue
+		}
+		if string(out) != tt.out {
+			t.Errorf("readPublication(%q) text = %q; want %q", tt.src, out, tt.out)
+		}
+		if pub.draft != tt.draft {
+			t.Errorf("readPublication(%q) draft = %v; want %v", tt.src, pub.draft, tt.draft)
+		}
+		if s := pub.scheduled(date, now); !s.Equal(tt.scheduled) {
+			t.Errorf("readPublication(%q) scheduled at %v; want %v", tt.src, s, tt.scheduled)
+		}
+	}
+
+	if _, _, err := readPublication([]byte("Go slices\n4 May 2015\nPublish: soon\n")); err == nil {
+		t.Errorf("readPublication accepted a bad publication time")
+	}
+}
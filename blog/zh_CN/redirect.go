@@ -0,0 +1,155 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redirectFile is the name of the redirect table in the content directory.
+const redirectFile = "redirects.txt"
+
+// redirects maps legacy URLs to articles. It is read from a file with one
+// redirect per line: an old path and the slug of the article it moved to.
+// An old path ending in /* is a prefix, which redirects every path below it
+// to the article named by the rest of the path; its slug is "*".
+type redirects struct {
+	file     string
+	paths    map[string]string // old path to slug
+	prefixes []string          // old path prefixes, without the /*
+	lines    map[string]int    // line of each old path and prefix
+}
+
+// readRedirects reads the redirect table in the file of fsys. A missing
+// file is an empty table.
+func readRedirects(fsys fs.FS, file string) (*redirects, error) {
+	f, err := fsys.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return parseRedirects(strings.NewReader(""), file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseRedirects(f, file)
+}
+
+// parseRedirects parses a redirect table read from r. Blank lines and
+// lines starting with # are ignored.
+func parseRedirects(r io.Reader, file string) (*redirects, error) {
+	rs := &redirects{
+		file:  file,
+		paths: make(map[string]string),
+		lines: make(map[string]int),
+	}
+	s := bufio.NewScanner(r)
+	for lineno := 1; s.Scan(); lineno++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			return nil, fmt.Errorf("%s:%d: want old path and slug", file, lineno)
+		}
+		from, slug := f[0], f[1]
+		if !strings.HasPrefix(from, "/") {
+			return nil, fmt.Errorf("%s:%d: old path %s does not start with /", file, lineno, from)
+		}
+		key := from
+		if strings.HasSuffix(from, "/*") {
+			if slug != "*" {
+				return nil, fmt.Errorf("%s:%d: prefix %s must redirect to *", file, lineno, from)
+			}
+			key = strings.TrimSuffix(from, "/*")
+		} else if slug == "*" || strings.ContainsAny(slug, "/.") {
+			return nil, fmt.Errorf("%s:%d: %s is not an article slug", file, lineno, slug)
+		}
+		if prev, ok := rs.lines[key]; ok {
+			return nil, fmt.Errorf("%s:%d: %s already redirected at line %d", file, lineno, from, prev)
+		}
+		rs.lines[key] = lineno
+		if slug == "*" {
+			rs.prefixes = append(rs.prefixes, key)
+		} else {
+			rs.paths[from] = slug
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	// Try prefixes before the shorter ones containing them.
+	sort.Sort(sort.Reverse(sort.StringSlice(rs.prefixes)))
+	return rs, nil
+}
+
+// check reports redirects to articles for which exists is false, and old
+// paths that are articles themselves.
+func (rs *redirects) check(exists func(slug string) bool) error {
+	var errs []string
+	for from, slug := range rs.paths {
+		if !exists(slug) {
+			errs = append(errs, fmt.Sprintf("%s:%d: redirect to unknown article %s", rs.file, rs.lines[from], slug))
+		}
+		if exists(strings.TrimPrefix(from, "/")) {
+			errs = append(errs, fmt.Sprintf("%s:%d: redirect from article %s", rs.file, rs.lines[from], from))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Strings(errs)
+	return fmt.Errorf("%s", strings.Join(errs, "\n"))
+}
+
+// legacyPath matches the paths of the articles of the old blog, such as
+// /2013/05/go-11-is-released.html, which the table may lack.
+var legacyPath = regexp.MustCompile(`^/\d{4}/\d{2}/[^/]+\.html$`)
+
+// legacy reports whether path is an old path of the table, below one of
+// its prefixes, or has the form of the paths of the old blog.
+func (rs *redirects) legacy(path string) bool {
+	if legacyPath.MatchString(path) {
+		return true
+	}
+	if _, ok := rs.paths[path]; ok {
+		return true
+	}
+	for _, p := range rs.prefixes {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the slug of the article to which path redirects. A path
+// that is a prefix itself redirects to the home page, with an empty slug.
+// Paths below a prefix only redirect to articles for which exists is true.
+func (rs *redirects) lookup(path string, exists func(slug string) bool) (slug string, ok bool) {
+	if slug, ok := rs.paths[path]; ok {
+		return slug, true
+	}
+	for _, p := range rs.prefixes {
+		if path == p || path == p+"/" {
+			return "", true
+		}
+		if strings.HasPrefix(path, p+"/") {
+			slug := path[len(p)+1:]
+			if exists(slug) {
+				return slug, true
+			}
+			return "", false
+		}
+	}
+	return "", false
+}
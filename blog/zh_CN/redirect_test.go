@@ -0,0 +1,154 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const contentDir = "content"
+
+// TestRedirectTable checks that the redirects of the blog lead to articles.
+func TestRedirectTable(t *testing.T) {
+	rs, err := readRedirects(os.DirFS(contentDir), redirectFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.paths) == 0 {
+		t.Fatalf("no redirects in %s", rs.file)
+	}
+	exists := func(slug string) bool {
+		_, err := os.Stat(filepath.Join(contentDir, slug+".article"))
+		return err == nil
+	}
+	if err := rs.check(exists); err != nil {
+		t.Error(err)
+	}
+}
+
+const testRedirects = `
+# comment
+/2013/05/go-11-is-released.html  go-11-is-released
+/blog/*  *
+/blog/old/*  *
+/2015/02/命名的学问.html  package-names
+`
+
+func TestRedirectLookup(t *testing.T) {
+	rs, err := parseRedirects(strings.NewReader(testRedirects), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists := func(slug string) bool {
+		return slug == "go-11-is-released" || slug == "package-names"
+	}
+	if err := rs.check(exists); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path string
+		slug string
+		ok   bool
+	}{
+		{"/2013/05/go-11-is-released.html", "go-11-is-released", true},
+		{"/2015/02/命名的学问.html", "package-names", true},
+		{"/blog", "", true},
+		{"/blog/", "", true},
+		{"/blog/package-names", "package-names", true},
+		{"/blog/old/package-names", "package-names", true},
+		{"/blog/no-such-article", "", false},
+		{"/blogger", "", false},
+		{"/2013/05/go-12-is-released.html", "", false},
+	} {
+		slug, ok := rs.lookup(tt.path, exists)
+		if slug != tt.slug || ok != tt.ok {
+			t.Errorf("lookup(%q) = %q, %v; want %q, %v", tt.path, slug, ok, tt.slug, tt.ok)
+		}
+	}
+
+	for path, want := range map[string]bool{
+		"/2013/05/go-11-is-released.html": true,
+		"/2013/05/go-12-is-released.html": true,
+		"/2013/05/sub/go-12.html":         false,
+		"/2013/5/go-12-is-released.html":  false,
+		"/blog/no-such-article":           true,
+		"/blog":                           true,
+		"/blogger":                        false,
+		"/package-names":                  false,
+	} {
+		if legacy := rs.legacy(path); legacy != want {
+			t.Errorf("legacy(%q) = %v; want %v", path, legacy, want)
+		}
+	}
+}
+
+func TestLegacyMisses(t *testing.T) {
+	content := fstest.MapFS{
+		"complete.article":  {Data: []byte(statusArticles["complete"])},
+		redirectFile:        {Data: []byte("/2015/02/complete.html complete\n/old/* *\n")},
+		"2016/01/kept.html": {Data: []byte("<p>kept</p>")},
+	}
+	addTrFiles(t, content)
+	m := newMetrics()
+	s, err := NewServer(Config{
+		Content:   content,
+		Templates: os.DirFS(templateDir),
+		Locale:    "zh-CN",
+		Metrics:   m,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path string
+		miss bool
+	}{
+		{"/2015/02/complete.html", false},
+		{"/old/complete", false},
+		{"/old/nosuch", true},
+		{"/2015/03/not-in-the-table.html", true},
+		{"/2016/01/kept.html", false},
+		{"/nosuch", false},
+	} {
+		before := m.legacyMisses
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+		if miss := m.legacyMisses > before; miss != tt.miss {
+			t.Errorf("%s: legacy miss %v; want %v", tt.path, miss, tt.miss)
+		}
+	}
+}
+
+func TestRedirectErrors(t *testing.T) {
+	for _, tt := range []struct {
+		table string
+		err   string
+	}{
+		{"/a.html a b", "test:1: want old path and slug"},
+		{"a.html a", "test:1: old path a.html does not start with /"},
+		{"/a/* a", "test:1: prefix /a/* must redirect to *"},
+		{"/a.html a.article", "test:1: a.article is not an article slug"},
+		{"/a.html a\n/a.html b", "test:2: /a.html already redirected at line 1"},
+	} {
+		_, err := parseRedirects(strings.NewReader(tt.table), "test")
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("parseRedirects(%q) = %v; want %s", tt.table, err, tt.err)
+		}
+	}
+
+	rs, err := parseRedirects(strings.NewReader("/a.html gone\n/kept.html kept\n/kept kept"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rs.check(func(slug string) bool { return slug == "kept" })
+	want := "test:1: redirect to unknown article gone\ntest:3: redirect from article /kept"
+	if err == nil || err.Error() != want {
+		t.Errorf("check = %v; want %s", err, want)
+	}
+}
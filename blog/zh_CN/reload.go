@@ -0,0 +1,144 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// reloadInterval is how often the reloader looks for changed files.
+const reloadInterval = time.Second
+
+// A reloader serves the blog with a Server that it rebuilds when the files
+// in its directories change, and when a scheduled article is due to be
+// published. If the rebuild fails, it keeps serving the last good Server,
+// whose pages show the error. Each rebuild checks the articles, as -check
+// does, and logs their problems.
+type reloader struct {
+	cfg   Config
+	dirs  []string
+	state atomic.Value // *reloadState
+}
+
+type reloadState struct {
+	srv   *Server  // last good server, or nil
+	err   error    // error of the last rebuild
+	diags []string // problems in the articles, found by checkContent
+}
+
+// newReloader serves srv, whose articles have the problems diags, or a
+// Server it builds from cfg if srv is nil, and starts rebuilding it when the
+// files in dirs change.
+func newReloader(cfg Config, srv *Server, diags []string, dirs ...string) *reloader {
+	rl := &reloader{cfg: cfg, dirs: dirs}
+	rl.state.Store(&reloadState{srv: srv, diags: diags})
+	stamp := rl.stamp()
+	if srv == nil {
+		rl.rebuild()
+	}
+	go func() {
+		for range time.Tick(reloadInterval) {
+			if s := rl.stamp(); s != stamp || rl.due() {
+				stamp = s
+				rl.rebuild()
+			}
+		}
+	}()
+	return rl
+}
+
+// rebuild checks the articles, logging their problems, then builds a new
+// Server and swaps it in. On error, it keeps the last good Server and
+// records the error.
+func (rl *reloader) rebuild() {
+	start := time.Now()
+	old := rl.state.Load().(*reloadState)
+	diags, err := checkContent(rl.cfg.Content)
+	if err != nil {
+		diags = old.diags
+	} else {
+		for _, d := range diags {
+			log.Printf("reload: %s", d)
+		}
+	}
+	var srv *Server
+	if err == nil {
+		srv, err = NewServer(rl.cfg)
+	}
+	if err != nil {
+		log.Printf("reload: %v", err)
+		rl.cfg.Metrics.reloadFailed()
+		rl.state.Store(&reloadState{srv: old.srv, err: err, diags: diags})
+		return
+	}
+	srv.reloadErr = rl.err
+	rl.state.Store(&reloadState{srv: srv, diags: diags})
+	log.Printf("reload: loaded in %v, %d problems in articles", time.Since(start), len(diags))
+}
+
+// due reports whether a scheduled article of the current Server is due to
+// be published.
+func (rl *reloader) due() bool {
+	srv := rl.state.Load().(*reloadState).srv
+	return srv != nil && !srv.nextPublish.IsZero() && !time.Now().Before(srv.nextPublish)
+}
+
+// ready returns why the reloader cannot serve the blog as it is in the
+// files, or nil if it can: the error of the last rebuild, or the problems
+// in the articles.
+func (rl *reloader) ready() error {
+	st := rl.state.Load().(*reloadState)
+	if st.err != nil {
+		return st.err
+	}
+	if st.srv == nil {
+		return errors.New("not loaded yet")
+	}
+	return problemsError(st.diags)
+}
+
+// problems returns the problems in the articles that the last rebuild
+// found, or that the reloader was started with.
+func (rl *reloader) problems() []string {
+	return rl.state.Load().(*reloadState).diags
+}
+
+// err returns the error of the last rebuild, or nil if it succeeded.
+func (rl *reloader) err() error {
+	return rl.state.Load().(*reloadState).err
+}
+
+// stamp returns a fingerprint of the names, sizes and modification times of
+// the files in the reloader's directories. Missing directories are empty.
+func (rl *reloader) stamp() uint64 {
+	h := fnv.New64a()
+	for _, dir := range rl.dirs {
+		filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(h, "%s %d %d\n", p, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return h.Sum64()
+}
+
+func (rl *reloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	st := rl.state.Load().(*reloadState)
+	if st.srv == nil {
+		http.Error(w, st.err.Error(), http.StatusInternalServerError)
+		return
+	}
+	st.srv.ServeHTTP(w, r)
+}
@@ -0,0 +1,46 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReloadChecks(t *testing.T) {
+	content := fstest.MapFS{
+		"complete.article": {Data: []byte(statusArticles["complete"])},
+	}
+	addTrFiles(t, content)
+	rl := &reloader{cfg: Config{
+		Content:   content,
+		Templates: os.DirFS(templateDir),
+		Locale:    "zh-CN",
+	}}
+	rl.state.Store(&reloadState{diags: []string{"startup problem"}})
+
+	content["broken.article"] = &fstest.MapFile{Data: []byte("#Broken\n坏块\n4 Feb 2015\n\nSameer Ajmani\n\n* S\n\n#en\na\n")}
+	rl.rebuild()
+	if err := rl.err(); err != nil {
+		t.Fatal(err)
+	}
+	if p := rl.problems(); len(p) != 1 || p[0] != "broken.article:9: unterminated block" {
+		t.Errorf("problems = %q; want the unterminated block of broken.article", p)
+	}
+	if err := rl.ready(); err == nil || !strings.Contains(err.Error(), "broken.article:9") {
+		t.Errorf("ready() = %v; want the problem of broken.article", err)
+	}
+
+	delete(content, "broken.article")
+	rl.rebuild()
+	if p := rl.problems(); len(p) != 0 {
+		t.Errorf("problems after the fix = %q; want none", p)
+	}
+	if err := rl.ready(); err != nil {
+		t.Errorf("ready() after the fix = %v", err)
+	}
+}
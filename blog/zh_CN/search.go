@@ -0,0 +1,316 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/present"
+)
+
+// The search index maps the words of the articles, in both languages, to
+// the articles they appear in. Text is split into tokens: runs of letters
+// and digits are words, and runs of Chinese characters, which are not
+// separated by spaces, are split into bigrams, the pairs of adjacent
+// characters, and into single characters. Queries are split the same way,
+// except that runs of several Chinese characters only give their bigrams,
+// and an article matches when it has all the tokens of the query.
+
+// Weights of the tokens of each part of an article.
+const (
+	titleWeight = 10
+	tagWeight   = 5
+	textWeight  = 1
+)
+
+// snippetLen is the length of the snippets of the search results, in
+// characters.
+const snippetLen = 120
+
+type searchIndex struct {
+	docs  []*searchDoc
+	terms map[string][]posting
+}
+
+// A searchDoc is an indexed article.
+type searchDoc struct {
+	path  string   // path without BasePath
+	paras []string // plain text paragraphs, for snippets
+	intro string   // plain text summary, for snippets without matches
+}
+
+// A posting records how often a token appears in docs[doc].
+type posting struct {
+	doc   int
+	score int // weighted count
+}
+
+// newSearchIndex indexes the articles of the server, in both languages.
+func (s *Server) newSearchIndex() *searchIndex {
+	x := &searchIndex{terms: make(map[string][]posting)}
+	for _, zh := range s.sites[langZH].docs {
+		p := strings.TrimPrefix(zh.Path, s.cfg.BasePath)
+		en := s.sites[langEN].docPaths[p]
+		d := &searchDoc{path: p, intro: stripTags(summary(zh))}
+		score := make(map[string]int)
+		add := func(text string, weight int) {
+			for _, tok := range tokenize(text, false) {
+				score[tok] += weight
+			}
+		}
+		seen := make(map[string]bool)
+		for _, doc := range []*Doc{zh, en} {
+			if doc == nil {
+				continue
+			}
+			add(doc.Title, titleWeight)
+			for _, para := range plainText(doc.Sections) {
+				if !seen[para] {
+					seen[para] = true
+					d.paras = append(d.paras, para)
+					add(para, textWeight)
+				}
+			}
+		}
+		for _, tag := range zh.Tags {
+			add(tag, tagWeight)
+			add(s.tagLabels.label(tag), tagWeight)
+		}
+		n := len(x.docs)
+		x.docs = append(x.docs, d)
+		for tok, sc := range score {
+			x.terms[tok] = append(x.terms[tok], posting{n, sc})
+		}
+	}
+	return x
+}
+
+// A searchResult is an article that matches a query.
+type searchResult struct {
+	Doc     *Doc
+	Snippet template.HTML
+	score   int
+}
+
+// searchData is the data of the search page.
+type searchData struct {
+	Query   string
+	Results []*searchResult
+}
+
+// search returns the articles of site that match query, the best first.
+func (x *searchIndex) search(site *site, query string) []*searchResult {
+	toks := tokenize(query, true)
+	if len(toks) == 0 {
+		return nil
+	}
+	score := make(map[int]int)
+	for i, tok := range toks {
+		found := make(map[int]int)
+		for _, p := range x.terms[tok] {
+			if _, ok := score[p.doc]; ok || i == 0 {
+				found[p.doc] = score[p.doc] + p.score
+			}
+		}
+		score = found
+	}
+	var results []*searchResult
+	for n, sc := range score {
+		d := x.docs[n]
+		doc, ok := site.docPaths[d.path]
+		if !ok {
+			continue
+		}
+		results = append(results, &searchResult{
+			Doc:     doc,
+			Snippet: d.snippet(toks),
+			score:   sc,
+		})
+	}
+	sort.Sort(resultsByScore(results))
+	return results
+}
+
+// resultsByScore sorts search results by decreasing score, and then by
+// time, the newest first.
+type resultsByScore []*searchResult
+
+func (s resultsByScore) Len() int      { return len(s) }
+func (s resultsByScore) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s resultsByScore) Less(i, j int) bool {
+	if s[i].score != s[j].score {
+		return s[i].score > s[j].score
+	}
+	return s[i].Doc.Time.After(s[j].Doc.Time)
+}
+
+// isHan reports whether r is a Chinese character.
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+// tokenize splits text into lower case tokens. If query is set, runs of
+// Chinese characters give their bigrams only, unless they are a single
+// character.
+func tokenize(text string, query bool) []string {
+	var toks []string
+	var word, han []rune
+	flush := func() {
+		if len(word) > 0 {
+			toks = append(toks, string(word))
+			word = word[:0]
+		}
+		for i := range han {
+			if !query || len(han) == 1 {
+				toks = append(toks, string(han[i]))
+			}
+			if i+1 < len(han) {
+				toks = append(toks, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isHan(r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return toks
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// stripTags returns the text of the HTML fragment s.
+func stripTags(s string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(s, "")))
+}
+
+// plainText returns the text of the paragraphs, list items and headings
+// of sections, without markup. Code is left out.
+func plainText(sections []present.Section) []string {
+	var paras []string
+	add := func(lines []string) {
+		var buf bytes.Buffer
+		for i, l := range lines {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(string(present.Style(l)))
+		}
+		if text := stripTags(buf.String()); text != "" {
+			paras = append(paras, text)
+		}
+	}
+	for _, sec := range sections {
+		add([]string{sec.Title})
+		for _, elem := range sec.Elem {
+			switch elem := elem.(type) {
+			case present.Section:
+				paras = append(paras, plainText([]present.Section{elem})...)
+			case present.Text:
+				if !elem.Pre {
+					add(elem.Lines)
+				}
+			case present.List:
+				for _, b := range elem.Bullet {
+					add([]string{b})
+				}
+			}
+		}
+	}
+	return paras
+}
+
+// snippet returns the start of the first paragraph of d that contains one
+// of toks, around the first match, with the matches in bold. If no
+// paragraph contains them, as when the article matches by its title or
+// tags, snippet returns the start of its summary.
+func (d *searchDoc) snippet(toks []string) template.HTML {
+	for _, para := range d.paras {
+		text := []rune(para)
+		if bold, first := matches(text, toks); first >= 0 {
+			return excerpt(text, bold, first)
+		}
+	}
+	text := []rune(d.intro)
+	return excerpt(text, make([]bool, len(text)), 0)
+}
+
+// matches marks the characters of text that are part of a token, ignoring
+// case, and returns the index of the first match, or -1.
+func matches(text []rune, toks []string) (bold []bool, first int) {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+	bold = make([]bool, len(text))
+	first = -1
+	for _, tok := range toks {
+		t := []rune(tok)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) != tok {
+				continue
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+			for j := range t {
+				bold[i+j] = true
+			}
+		}
+	}
+	return bold, first
+}
+
+// excerpt returns snippetLen characters of text around index first, with
+// the bold ones in bold.
+func excerpt(text []rune, bold []bool, first int) template.HTML {
+	start := first - snippetLen/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLen
+	if end > len(text) {
+		end = len(text)
+	}
+	var buf bytes.Buffer
+	if start > 0 {
+		buf.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && bold[j] == bold[i] {
+			j++
+		}
+		s := template.HTMLEscapeString(string(text[i:j]))
+		if bold[i] {
+			s = "<b>" + s + "</b>"
+		}
+		buf.WriteString(s)
+		i = j
+	}
+	if end < len(text) {
+		buf.WriteString("…")
+	}
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,48 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	for _, tt := range []struct {
+		text  string
+		query bool
+		toks  []string
+	}{
+		{"Go Concurrency, 2014", false, []string{"go", "concurrency", "2014"}},
+		{"并发", false, []string{"并", "并发", "发"}},
+		{"Go语言的并发", false, []string{"go", "语", "语言", "言", "言的", "的", "的并", "并", "并发", "发"}},
+		{"Go语言的并发", true, []string{"go", "语言", "言的", "的并", "并发"}},
+		{"并 defer", true, []string{"并", "defer"}},
+		{"“命名”", true, []string{"命名"}},
+	} {
+		if toks := tokenize(tt.text, tt.query); !reflect.DeepEqual(toks, tt.toks) {
+			t.Errorf("tokenize(%q, %v) = %q; want %q", tt.text, tt.query, toks, tt.toks)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	d := &searchDoc{
+		paras: []string{"Defer 语句", "使用 <defer> 和 panic 可以简化错误处理."},
+		intro: "Go 有一些常见的控制流程.",
+	}
+	for _, tt := range []struct {
+		query   string
+		snippet string
+	}{
+		{"panic", "使用 &lt;defer&gt; 和 <b>panic</b> 可以简化错误处理."},
+		{"错误 panic", "使用 &lt;defer&gt; 和 <b>panic</b> 可以简化<b>错误</b>处理."},
+		{"控制", "Go 有一些常见的控制流程."},
+	} {
+		if s := string(d.snippet(tokenize(tt.query, true))); s != tt.snippet {
+			t.Errorf("snippet(%q) = %q; want %q", tt.query, s, tt.snippet)
+		}
+	}
+}
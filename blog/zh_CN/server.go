@@ -0,0 +1,626 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file is derived from golang.org/x/tools/blog, and extended to serve
+// the bilingual articles of this blog in the language of the reader.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/present"
+)
+
+var validJSONPFunc = regexp.MustCompile(`(?i)^[a-z_][a-z0-9_.]*$`)
+
+// Config specifies Server configuration values. The settings other than
+// the file systems are read from the JSON config file of the blog.
+type Config struct {
+	Content   fs.FS `json:"-"` // Article files and related content.
+	Templates fs.FS `json:"-"` // Template files.
+	Static    fs.FS `json:"-"` // Static files, served below /static/.
+
+	BaseURL  string // Absolute base URL (for permalinks; no trailing slash).
+	BasePath string // Base URL path relative to server root (no trailing slash).
+	GodocURL string // The base URL of godoc (for menu bar; no trailing slash).
+	Hostname string // Server host name, used for rendering ATOM feeds.
+
+	// Absolute base URL of the English blog, from which the articles are
+	// translated (no trailing slash).
+	UpstreamURL string
+
+	Locale string // Locale of the messages of the pages, such as zh-CN.
+
+	HomeArticles        int    // Articles to display on the home page.
+	FeedArticles        int    // Articles to include in Atom and JSON feeds.
+	FeedTitle           string // The title of the Atom XML feed of all articles
+	TranslatedFeedTitle string // The title of the feed of translated articles
+
+	PlayEnabled bool `json:"-"`
+
+	// Drafts makes the server publish the drafts and the articles
+	// scheduled to be published later, marked as such.
+	Drafts bool `json:"-"`
+
+	// Metrics, if set, records the legacy paths that lead to no article
+	// and the failed reloads.
+	Metrics *metrics `json:"-"`
+
+	// PreviewToken, if set, is the value of the preview parameter with
+	// which readers see the pages of drafts and scheduled articles.
+	PreviewToken string
+}
+
+// Doc represents an article adorned with presentation data.
+type Doc struct {
+	*present.Doc
+	Permalink string        // Canonical URL for this document.
+	Original  string        // URL of the English original of this document.
+	Path      string        // Path relative to server root (including base).
+	HTML      template.HTML // rendered article
+	Status    transStatus   // how much of the article is translated
+	Draft     bool          // the article is a draft
+	Scheduled time.Time     // when the article is published, if later
+
+	Related      []*Doc
+	Newer, Older *Doc
+
+	// Translations lists the translated articles on the same topic as
+	// an untranslated article.
+	Translations []*Doc
+}
+
+// A site holds the articles of the blog rendered in one language.
+type site struct {
+	docs     []*Doc
+	tags     []string
+	docPaths map[string]*Doc // key is path without BasePath.
+	docTags  map[string][]*Doc
+	archive  []*archiveYear  // newest first
+	hidden   map[string]*Doc // unpublished docs; key is path without BasePath.
+}
+
+// Server implements an http.Handler that serves blog articles.
+type Server struct {
+	cfg       Config
+	sites     map[string]*site // key is language.
+	redirect  *redirects
+	upstreams *upstreams
+	tagLabels tagLabels
+	catalog   *catalog
+	assets    *assets
+	search    *searchIndex
+	template  struct {
+		home, index, article, doc *template.Template
+		translations              *template.Template
+		tag, tags, archive        *template.Template
+		search                    *template.Template
+	}
+	feed     *feed            // feeds of all articles
+	tfeed    *feed            // feeds of completely translated articles
+	tagFeeds map[string]*feed // feeds of the articles of each tag
+	content  http.Handler
+
+	nextPublish time.Time // when the next scheduled article is published, if any
+
+	exporting bool         // pages are being exported as static files
+	reloadErr func() error // error reloading the server from changed files, if set
+}
+
+// NewServer constructs a new Server using the specified config.
+func NewServer(cfg Config) (*Server, error) {
+	present.PlayEnabled = cfg.PlayEnabled
+
+	s := &Server{cfg: cfg}
+
+	// Read the messages of the templates.
+	var err error
+	s.catalog, err = readCatalog(cfg.Templates, cfg.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the static files, to which the templates refer.
+	s.assets, err = newAssets(cfg.Static)
+	if err != nil {
+		return nil, err
+	}
+
+	parse := func(name string) (*template.Template, error) {
+		t := template.New("").Funcs(funcMap).Funcs(template.FuncMap{
+			"tag":   s.tag,
+			"msg":   s.catalog.msg,
+			"asset": s.assets.url,
+		})
+		return t.ParseFS(cfg.Templates, "root.tmpl", name)
+	}
+
+	// Parse templates.
+	s.template.home, err = parse("home.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	s.template.index, err = parse("index.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	s.template.article, err = parse("article.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	s.template.translations, err = parse("translations.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	s.template.tag, err = parse("tag.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	s.template.tags, err = parse("tags.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	s.template.archive, err = parse("archive.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	s.template.search, err = parse("search.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	p := present.Template().Funcs(funcMap)
+	s.template.doc, err = p.ParseFS(cfg.Templates, "doc.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	// Load content, linked to the originals.
+	s.upstreams, err = readUpstreams(cfg.Content, upstreamFile)
+	if err != nil {
+		return nil, err
+	}
+	err = s.loadDocs(cfg.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load redirects, which must lead to articles.
+	s.redirect, err = readRedirects(cfg.Content, redirectFile)
+	if err != nil {
+		return nil, err
+	}
+	err = s.redirect.check(s.exists)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the originals, and link the untranslated articles to the
+	// translated ones.
+	site := s.sites[defaultLang]
+	docs := site.docs
+	for _, d := range site.hidden {
+		docs = append(docs, d)
+	}
+	err = s.upstreams.check(s.exists, docs)
+	if err != nil {
+		return nil, err
+	}
+	for _, site := range s.sites {
+		s.linkTranslations(site)
+	}
+
+	s.tagLabels, err = readTagLabels(cfg.Content, tagFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index the articles for search.
+	s.search = s.newSearchIndex()
+
+	// Render the feeds, in Chinese.
+	docs = s.sites[langZH].docs
+	s.feed, err = s.newFeed(cfg.FeedTitle, "/feed.atom", docs)
+	if err != nil {
+		return nil, err
+	}
+	var translated []*Doc
+	for _, d := range docs {
+		if d.Status == complete {
+			translated = append(translated, d)
+		}
+	}
+	s.tfeed, err = s.newFeed(cfg.TranslatedFeedTitle, "/translated.atom", translated)
+	if err != nil {
+		return nil, err
+	}
+	err = s.newTagFeeds()
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up content file server.
+	s.content = http.StripPrefix(s.cfg.BasePath, http.FileServer(http.FS(cfg.Content)))
+
+	return s, nil
+}
+
+var funcMap = template.FuncMap{
+	"sectioned": sectioned,
+	"authors":   authors,
+}
+
+// sectioned returns true if the provided Doc contains more than one section.
+// This is used to control whether to display the table of contents and headings.
+func sectioned(d *present.Doc) bool {
+	return len(d.Sections) > 1
+}
+
+// authors returns a comma-separated list of author names.
+func authors(authors []present.Author) string {
+	var b bytes.Buffer
+	last := len(authors) - 1
+	for i, a := range authors {
+		if i > 0 {
+			if i == last {
+				b.WriteString(" and ")
+			} else {
+				b.WriteString(", ")
+			}
+		}
+		b.WriteString(authorName(a))
+	}
+	return b.String()
+}
+
+// authorName returns the first line of the Author text: the author's name.
+func authorName(a present.Author) string {
+	el := a.TextElem()
+	if len(el) == 0 {
+		return ""
+	}
+	text, ok := el[0].(present.Text)
+	if !ok || len(text.Lines) == 0 {
+		return ""
+	}
+	return text.Lines[0]
+}
+
+// loadDocs reads all content from the provided file system, renders all
+// the articles it finds in each language, and builds a site for each
+// language from the published ones.
+func (s *Server) loadDocs(fsys fs.FS) error {
+	// Read content into docs, for each language.
+	docs := make(map[string][]*Doc)
+	ctx := presentContext(fsys)
+	now := time.Now()
+	const ext = ".article"
+	fn := func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path.Ext(p) != ext {
+			return nil
+		}
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		slug := "/" + p[:len(p)-len(ext)] // trim extension
+		pub, src, err := readPublication(src)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+		status := translationStatus(src)
+		texts := make(map[string][]byte)
+		for _, lang := range langs {
+			texts[lang], err = filterLang(src, lang)
+			if err != nil {
+				// Serve the article as written, with both
+				// languages, until its blocks are fixed.
+				log.Printf("%s: %v", p, err)
+				for _, lang := range langs {
+					texts[lang] = src
+				}
+				break
+			}
+		}
+		for _, lang := range langs {
+			d, err := ctx.Parse(bytes.NewReader(texts[lang]), p, 0)
+			if err != nil {
+				return err
+			}
+			var html bytes.Buffer
+			err = d.Render(&html, s.template.doc)
+			if err != nil {
+				return err
+			}
+			docs[lang] = append(docs[lang], &Doc{
+				Doc:       d,
+				Path:      s.cfg.BasePath + slug,
+				Permalink: s.cfg.BaseURL + slug,
+				Original:  s.upstreams.original(s.cfg.UpstreamURL, strings.TrimPrefix(slug, "/")),
+				HTML:      template.HTML(html.String()),
+				Status:    status,
+				Draft:     pub.draft,
+				Scheduled: pub.scheduled(d.Time, now),
+			})
+		}
+		return nil
+	}
+	err := fs.WalkDir(fsys, ".", fn)
+	if err != nil {
+		return err
+	}
+	s.sites = make(map[string]*site)
+	for _, lang := range langs {
+		var published []*Doc
+		hidden := make(map[string]*Doc)
+		for _, d := range docs[lang] {
+			if s.cfg.Drafts || !d.Unpublished() {
+				published = append(published, d)
+				continue
+			}
+			hidden[strings.TrimPrefix(d.Path, s.cfg.BasePath)] = d
+			if t := d.Scheduled; !d.Draft && (s.nextPublish.IsZero() || t.Before(s.nextPublish)) {
+				s.nextPublish = t
+			}
+		}
+		s.sites[lang] = newSite(s.cfg.BasePath, published)
+		s.sites[lang].hidden = hidden
+	}
+	return nil
+}
+
+// presentContext returns a context for parsing the articles in fsys, from
+// which the commands of an article read the files they name, relative to
+// the article.
+func presentContext(fsys fs.FS) *present.Context {
+	return &present.Context{ReadFile: func(name string) ([]byte, error) {
+		// present joins the names with the directory of the article.
+		return fs.ReadFile(fsys, path.Clean(filepath.ToSlash(name)))
+	}}
+}
+
+// newSite sorts docs, computes the denormalized docPaths, docTags, tags, and
+// archive fields, and populates the various helper fields (Next, Previous, Related)
+// for each Doc.
+func newSite(basePath string, docs []*Doc) *site {
+	s := &site{docs: docs}
+	sort.Sort(docsByTime(s.docs))
+
+	// Pull out doc paths and tags and put in reverse-associating maps.
+	s.docPaths = make(map[string]*Doc)
+	s.docTags = make(map[string][]*Doc)
+	for _, d := range s.docs {
+		s.docPaths[strings.TrimPrefix(d.Path, basePath)] = d
+		for _, t := range d.Tags {
+			s.docTags[t] = append(s.docTags[t], d)
+		}
+	}
+
+	// Pull out unique sorted list of tags.
+	for t := range s.docTags {
+		s.tags = append(s.tags, t)
+	}
+	sort.Strings(s.tags)
+
+	s.archive = newArchive(basePath, s.docs)
+
+	// Set up presentation-related fields, Newer, Older, and Related.
+	for _, doc := range s.docs {
+		// Newer, Older: docs adjacent to doc
+		for i := range s.docs {
+			if s.docs[i] != doc {
+				continue
+			}
+			if i > 0 {
+				doc.Newer = s.docs[i-1]
+			}
+			if i+1 < len(s.docs) {
+				doc.Older = s.docs[i+1]
+			}
+			break
+		}
+
+		// Related: all docs that share tags with doc.
+		related := make(map[*Doc]bool)
+		for _, t := range doc.Tags {
+			for _, d := range s.docTags[t] {
+				if d != doc {
+					related[d] = true
+				}
+			}
+		}
+		for d := range related {
+			doc.Related = append(doc.Related, d)
+		}
+		sort.Sort(docsByTime(doc.Related))
+	}
+	return s
+}
+
+// rootData encapsulates data destined for the root template.
+type rootData struct {
+	Doc      *Doc
+	BasePath string
+	GodocURL string
+	Lang     string            // language of the page, for the language switch
+	LangURLs map[string]string // URL of the page in each language, by language
+	Export   bool              // the page is a static file, served without this server
+	Data     interface{}
+
+	Alternates  []alternate // versions of the page in each language
+	ReloadError string      // why the page is not up to date with the files
+}
+
+// ServeHTTP serves the front, index, and article pages
+// as well as the ATOM and JSON feeds, the sitemap and the static files.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		d = rootData{
+			BasePath: s.cfg.BasePath,
+			GodocURL: s.cfg.GodocURL,
+			Export:   s.exporting,
+		}
+		t *template.Template
+	)
+	if s.assets.serve(w, r) {
+		return
+	}
+	if s.reloadErr != nil {
+		if err := s.reloadErr(); err != nil {
+			d.ReloadError = err.Error()
+		}
+	}
+	p := strings.TrimPrefix(r.URL.Path, s.cfg.BasePath)
+	switch p {
+	case "/feed.atom", "/feeds/posts/default":
+		s.feed.serveAtom(w, r)
+		return
+	case "/.json":
+		s.feed.serveJSON(w, r)
+		return
+	case "/translated.atom":
+		s.tfeed.serveAtom(w, r)
+		return
+	case "/translated.json":
+		s.tfeed.serveJSON(w, r)
+		return
+	case "/sitemap.xml":
+		s.serveSitemap(w, r)
+		return
+	default:
+		if s.serveTagFeed(w, r, p) {
+			return
+		}
+		d.Lang = requestLang(w, r)
+		d.LangURLs = langURLs(r.URL)
+		site := s.sites[d.Lang]
+		switch p {
+		case "/":
+			d.Data = site.docs
+			if len(site.docs) > s.cfg.HomeArticles {
+				d.Data = site.docs[:s.cfg.HomeArticles]
+			}
+			t = s.template.home
+		case "/index":
+			d.Data = site.docs
+			t = s.template.index
+		case "/translations":
+			d.Data = translations(site.docs)
+			t = s.template.translations
+		case "/tags":
+			d.Data = s.tagCloud(site)
+			t = s.template.tags
+		case "/search":
+			q := strings.TrimSpace(r.FormValue("q"))
+			d.Data = searchData{Query: q, Results: s.search.search(site, q)}
+			t = s.template.search
+		default:
+			if name := strings.TrimPrefix(p, "/tag/"); name != p {
+				docs, ok := site.docTags[name]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				d.Data = tagData{Tag: s.tag(name), Docs: docs}
+				t = s.template.tag
+				break
+			}
+			if a, ok := site.archivePage(s.cfg.BasePath, r.URL.Path); ok {
+				d.Data = a
+				t = s.template.archive
+				break
+			}
+			doc, ok := site.docPaths[p]
+			if !ok && site.hidden[p] != nil && s.preview(w, r) {
+				doc, ok = site.hidden[p], true
+			}
+			if !ok {
+				if slug, ok := s.redirect.lookup(p, s.exists); ok {
+					http.Redirect(w, r, s.cfg.BasePath+"/"+slug, http.StatusMovedPermanently)
+					return
+				}
+				// Not a doc; try to just serve static content.
+				if s.redirect.legacy(p) {
+					sw := &statusWriter{ResponseWriter: w}
+					s.content.ServeHTTP(sw, r)
+					if sw.code == http.StatusNotFound {
+						s.cfg.Metrics.legacyMiss()
+					}
+					return
+				}
+				s.content.ServeHTTP(w, r)
+				return
+			}
+			d.Doc = doc
+			t = s.template.article
+		}
+	}
+	q := r.URL.Query()
+	q.Del("lang")
+	d.Alternates = s.alternates(p, q, s.upstream(p))
+	// The page depends on the language chosen by the reader.
+	w.Header().Set("Vary", "Cookie")
+	err := t.ExecuteTemplate(w, "root", d)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// exists reports whether there is an article with the given slug,
+// published or not.
+func (s *Server) exists(slug string) bool {
+	site := s.sites[defaultLang]
+	return site.docPaths["/"+slug] != nil || site.hidden["/"+slug] != nil
+}
+
+// A transGroup lists the articles with the same translation status.
+type transGroup struct {
+	Status transStatus
+	Docs   []*Doc
+}
+
+// transData is the data of the translations page.
+type transData struct {
+	Groups   []transGroup // articles that are not completely translated
+	Complete int          // number of completely translated articles
+}
+
+// translations groups the articles in docs that are not completely
+// translated by status, the least translated first.
+func translations(docs []*Doc) transData {
+	var data transData
+	data.Groups = make([]transGroup, complete)
+	for i := range data.Groups {
+		data.Groups[i].Status = transStatus(i)
+	}
+	for _, d := range docs {
+		if d.Status == complete {
+			data.Complete++
+			continue
+		}
+		data.Groups[d.Status].Docs = append(data.Groups[d.Status].Docs, d)
+	}
+	return data
+}
+
+// docsByTime implements sort.Interface, sorting Docs by their Time field.
+type docsByTime []*Doc
+
+func (s docsByTime) Len() int           { return len(s) }
+func (s docsByTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s docsByTime) Less(i, j int) bool { return s[i].Time.After(s[j].Time) }
@@ -0,0 +1,175 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/xml"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// An alternate is a version of a page in a language, which the page and
+// the sitemap list for search engines with rel="alternate" hreflang links.
+type alternate struct {
+	Lang string // language tag, or x-default
+	URL  string
+}
+
+// alternates returns the alternates of the page at path p, without
+// BasePath, with the query parameters query. They are the pages served here
+// in Chinese and, for x-default, in the language chosen by the reader, and
+// the page in English: upstream, the page of the original blog, if there is
+// one, or else the page served here in English. Exported pages are only in
+// Chinese, at their plain URL. An article is only an alternate in Chinese
+// if some of its text is translated: a translated title does not make the
+// page Chinese.
+func (s *Server) alternates(p string, query url.Values, upstream string) []alternate {
+	page := func(lang string) string {
+		q := make(url.Values)
+		for k, v := range query {
+			q[k] = v
+		}
+		if lang != "" {
+			q.Set("lang", lang)
+		}
+		u := absURL(s.cfg.BaseURL + p)
+		if len(q) > 0 {
+			u += "?" + q.Encode()
+		}
+		return u
+	}
+	zh := s.translated(p)
+	if s.exporting {
+		var alts []alternate
+		if zh {
+			alts = append(alts, alternate{"zh-CN", page("")})
+		}
+		if upstream != "" {
+			alts = append(alts, alternate{"en", upstream})
+		}
+		return alts
+	}
+	// hreflang allows one URL per language, and the original is the
+	// English page that search engines should show, so the page served
+	// here in English is only listed when there is no original. The page
+	// in both languages is never listed: it is in no single language.
+	en := upstream
+	if en == "" {
+		en = page(langEN)
+	}
+	var alts []alternate
+	if zh {
+		alts = append(alts, alternate{"zh-CN", page(langZH)})
+	}
+	return append(alts,
+		alternate{"en", en},
+		alternate{"x-default", page("")},
+	)
+}
+
+// translated reports whether the page at path p, without BasePath, is in
+// Chinese: it is not an article, or an article partly or completely
+// translated.
+func (s *Server) translated(p string) bool {
+	d, ok := s.sites[defaultLang].docPaths[p]
+	return !ok || d.Status.Translated()
+}
+
+// upstream returns the URL of the page at path p, without BasePath, on the
+// original blog, or "" if it has none. The original blog has the articles,
+// its home page and its index.
+func (s *Server) upstream(p string) string {
+	if s.cfg.UpstreamURL == "" {
+		return ""
+	}
+	if p == "/" || p == "/index" {
+		return s.cfg.UpstreamURL + p
+	}
+	if d, ok := s.sites[defaultLang].docPaths[p]; ok {
+		return d.Original
+	}
+	return ""
+}
+
+// absURL returns the URL u, which may be relative to the scheme, as search
+// engines want it: absolute.
+func absURL(u string) string {
+	if strings.HasPrefix(u, "//") {
+		return "https:" + u
+	}
+	return u
+}
+
+// A sitemap lists the pages of the blog for search engines, in the format
+// of sitemaps.org, with the alternates of each page.
+type sitemap struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	XHTML   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string        `xml:"loc"`
+	LastMod string        `xml:"lastmod,omitempty"`
+	Links   []sitemapLink `xml:"xhtml:link"`
+}
+
+type sitemapLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// newSitemap returns the sitemap of the blog: its home page, index and tag
+// cloud, the articles, the tag pages and the archive pages. Each page was
+// last modified at the date of the newest of its articles.
+func (s *Server) newSitemap() *sitemap {
+	sm := &sitemap{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XHTML: "http://www.w3.org/1999/xhtml",
+	}
+	add := func(p string, docs []*Doc) {
+		u := sitemapURL{Loc: absURL(s.cfg.BaseURL + p)}
+		if len(docs) > 0 {
+			u.LastMod = docs[0].Time.Format("2006-01-02") // docs are newest first
+		}
+		for _, a := range s.alternates(p, nil, s.upstream(p)) {
+			u.Links = append(u.Links, sitemapLink{"alternate", a.Lang, a.URL})
+		}
+		sm.URLs = append(sm.URLs, u)
+	}
+	site := s.sites[defaultLang]
+	add("/", site.docs)
+	add("/index", site.docs)
+	add("/tags", site.docs)
+	for _, d := range site.docs {
+		add(strings.TrimPrefix(d.Path, s.cfg.BasePath), []*Doc{d})
+	}
+	for _, tag := range site.tags {
+		add("/tag/"+tag, site.docTags[tag])
+	}
+	add("/archive", site.docs)
+	for _, y := range site.archive {
+		add(strings.TrimPrefix(y.Path, s.cfg.BasePath), y.Months[0].Docs)
+		for _, m := range y.Months {
+			add(strings.TrimPrefix(m.Path, s.cfg.BasePath), m.Docs)
+		}
+	}
+	return sm
+}
+
+// serveSitemap serves the sitemap of the blog.
+func (s *Server) serveSitemap(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	e := xml.NewEncoder(w)
+	e.Indent("", "\t")
+	if err := e.Encode(s.newSitemap()); err != nil {
+		log.Printf("sitemap: %v", err)
+	}
+}
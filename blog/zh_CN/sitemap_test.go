@@ -0,0 +1,73 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestAlternates(t *testing.T) {
+	s := &Server{
+		cfg: Config{
+			BaseURL:     "//blog.example.com",
+			UpstreamURL: "https://blog.golang.org",
+		},
+		sites: map[string]*site{
+			defaultLang: {docPaths: map[string]*Doc{
+				"/package-names": {Original: "https://blog.golang.org/package-names", Status: partial},
+				"/title-only":    {Original: "https://blog.golang.org/title-only", Status: titleOnly},
+				"/untranslated":  {Status: untranslated},
+			}},
+		},
+	}
+	for _, tt := range []struct {
+		path      string
+		query     url.Values
+		exporting bool
+		alts      []alternate
+	}{
+		{"/package-names", nil, false, []alternate{
+			{"zh-CN", "https://blog.example.com/package-names?lang=zh"},
+			{"en", "https://blog.golang.org/package-names"},
+			{"x-default", "https://blog.example.com/package-names"},
+		}},
+		{"/title-only", nil, false, []alternate{
+			{"en", "https://blog.golang.org/title-only"},
+			{"x-default", "https://blog.example.com/title-only"},
+		}},
+		{"/untranslated", nil, false, []alternate{
+			{"en", "https://blog.example.com/untranslated?lang=en"},
+			{"x-default", "https://blog.example.com/untranslated"},
+		}},
+		{"/tags", nil, false, []alternate{
+			{"zh-CN", "https://blog.example.com/tags?lang=zh"},
+			{"en", "https://blog.example.com/tags?lang=en"},
+			{"x-default", "https://blog.example.com/tags"},
+		}},
+		{"/search", url.Values{"q": {"并发"}}, false, []alternate{
+			{"zh-CN", "https://blog.example.com/search?lang=zh&q=%E5%B9%B6%E5%8F%91"},
+			{"en", "https://blog.example.com/search?lang=en&q=%E5%B9%B6%E5%8F%91"},
+			{"x-default", "https://blog.example.com/search?q=%E5%B9%B6%E5%8F%91"},
+		}},
+		{"/", nil, true, []alternate{
+			{"zh-CN", "https://blog.example.com/"},
+			{"en", "https://blog.golang.org/"},
+		}},
+		{"/tags", nil, true, []alternate{
+			{"zh-CN", "https://blog.example.com/tags"},
+		}},
+		{"/title-only", nil, true, []alternate{
+			{"en", "https://blog.golang.org/title-only"},
+		}},
+	} {
+		s.exporting = tt.exporting
+		alts := s.alternates(tt.path, tt.query, s.upstream(tt.path))
+		if !reflect.DeepEqual(alts, tt.alts) {
+			t.Errorf("alternates(%q, %v), exporting %v = %v; want %v", tt.path, tt.query, tt.exporting, alts, tt.alts)
+		}
+	}
+}
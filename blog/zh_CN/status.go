@@ -0,0 +1,101 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A transStatus tells how much of an article is translated.
+type transStatus int
+
+const (
+	untranslated transStatus = iota
+	titleOnly                // only the title is translated
+	partial                  // some paragraphs are translated
+	complete
+)
+
+// String returns the name of s, which the templates use as a CSS class and
+// in the key of its message, status.<name>.
+func (s transStatus) String() string {
+	switch s {
+	case titleOnly:
+		return "title-only"
+	case partial:
+		return "partial"
+	case complete:
+		return "complete"
+	}
+	return "untranslated"
+}
+
+// Translated reports whether some of the text of the article is
+// translated, beyond its title.
+func (s transStatus) Translated() bool {
+	return s == partial || s == complete
+}
+
+// translationStatus returns the translation status of the article src.
+//
+// An article is complete when its title and every original block have a
+// translation, and partial when only some of them do. Text outside of the
+// blocks, such as code, is not counted.
+func translationStatus(src []byte) transStatus {
+	lines := strings.SplitAfter(string(src), "\n")
+	n := headerEnd(lines)
+	title := titleTranslated(lines[:n])
+
+	blocks, err := splitBlocks(lines[n:], n)
+	if err != nil {
+		// The blocks are broken; all we can tell is whether
+		// there are translations.
+		for _, line := range lines[n:] {
+			if m := marker(line); m == beginZH || m == markZH {
+				return partial
+			}
+		}
+		if title {
+			return titleOnly
+		}
+		return untranslated
+	}
+	translated, missing := 0, 0
+	for i, b := range blocks {
+		switch {
+		case b.lang == langZH:
+			translated++
+		case b.lang == langEN && !pairedWith(blocks[i+1:], langZH):
+			missing++
+		}
+	}
+	switch {
+	case translated == 0 && title:
+		return titleOnly
+	case translated == 0:
+		return untranslated
+	case missing > 0 || !title:
+		return partial
+	}
+	return complete
+}
+
+// titleTranslated reports whether the title in an article header is in
+// Chinese, possibly below the original commented out.
+func titleTranslated(header []string) bool {
+	for _, line := range header {
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		for _, r := range line {
+			if unicode.Is(unicode.Han, r) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// Articles in each translation status.
+var statusArticles = map[string]string{
+	"untranslated": `Untranslated
+4 Feb 2015
+
+Sameer Ajmani
+
+* Introduction
+
+Go code is organized into packages.
+
+	#zh
+	code, with a line that reads like a marker
+`,
+	"title-only": `#Title only
+只有标题
+4 Feb 2015
+
+Sameer Ajmani
+
+* Introduction
+
+Go code is organized into packages.
+`,
+	"partial": `#Partial
+部分翻译
+4 Feb 2015
+
+Sameer Ajmani
+
+* Introduction
+
+#en
+Go code is organized into packages.
+#zh
+Go 代码以包来组织。
+#end
+
+#en
+An original without translation.
+#end
+`,
+	"complete": `#Complete
+全部翻译
+4 Feb 2015
+
+Sameer Ajmani
+
+* Introduction
+
+#en
+Go code is organized into packages.
+#zh
+Go 代码以包来组织。
+#end
+
+	func main() {}
+`,
+}
+
+func TestTranslationStatus(t *testing.T) {
+	for _, tt := range []struct {
+		name, src string
+		status    transStatus
+	}{
+		{"untranslated", statusArticles["untranslated"], untranslated},
+		{"title-only", statusArticles["title-only"], titleOnly},
+		{"partial", statusArticles["partial"], partial},
+		{"complete", statusArticles["complete"], complete},
+		{
+			name:   "translated blocks, English title",
+			src:    "Blocks\n4 Feb 2015\n\n* S\n\n#en\na\n#zh\n甲\n#end\n",
+			status: partial,
+		},
+		{
+			name:   "_tr includes",
+			src:    "#Includes\n包含\n4 Feb 2015\n\n* S\n\n.html _tr/div_begin_en.html\na\n.html _tr/div_end.html\n.html _tr/div_begin_zh_CN.html\n甲\n.html _tr/div_end.html\n",
+			status: complete,
+		},
+		{
+			name:   "broken blocks",
+			src:    "#Broken\n坏块\n4 Feb 2015\n\n* S\n\n#en\na\n#zh\n甲\n",
+			status: partial,
+		},
+		{
+			name:   "broken blocks, no translation",
+			src:    "#Broken\n坏块\n4 Feb 2015\n\n* S\n\n#en\na\n",
+			status: titleOnly,
+		},
+	} {
+		if s := translationStatus([]byte(tt.src)); s != tt.status {
+			t.Errorf("%s: translationStatus = %v; want %v", tt.name, s, tt.status)
+		}
+	}
+}
+
+func TestTranslated(t *testing.T) {
+	for s, want := range map[transStatus]bool{
+		untranslated: false,
+		titleOnly:    false,
+		partial:      true,
+		complete:     true,
+	} {
+		if s.Translated() != want {
+			t.Errorf("%v.Translated() = %v; want %v", s, !want, want)
+		}
+	}
+}
+
+// addTrFiles adds to content the files that bilingual articles include in
+// both languages.
+func addTrFiles(t *testing.T, content fstest.MapFS) {
+	for _, name := range []string{"_tr/div_begin_en.html", "_tr/div_begin_zh_CN.html", "_tr/div_end.html"} {
+		data, err := ioutil.ReadFile(filepath.Join(contentDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content[name] = &fstest.MapFile{Data: data}
+	}
+}
+
+func TestTranslationsPage(t *testing.T) {
+	content := make(fstest.MapFS)
+	for slug, src := range statusArticles {
+		content[slug+".article"] = &fstest.MapFile{Data: []byte(src)}
+	}
+	addTrFiles(t, content)
+	s, err := NewServer(Config{
+		Content:   content,
+		Templates: os.DirFS(templateDir),
+		Locale:    "zh-CN",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/translations", nil))
+	body := w.Body.String()
+	for _, slug := range []string{"untranslated", "title-only", "partial"} {
+		if !strings.Contains(body, `<a href="/`+slug+`">`) {
+			t.Errorf("/translations does not list %s", slug)
+		}
+	}
+	if strings.Contains(body, `<a href="/complete">`) {
+		t.Errorf("/translations lists the complete article")
+	}
+}
+
+func TestOriginalNote(t *testing.T) {
+	content := make(fstest.MapFS)
+	for slug, src := range statusArticles {
+		content[slug+".article"] = &fstest.MapFile{Data: []byte(src)}
+	}
+	addTrFiles(t, content)
+	s, err := NewServer(Config{
+		Content:     content,
+		Templates:   os.DirFS(templateDir),
+		Locale:      "zh-CN",
+		UpstreamURL: "https://blog.golang.org",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for slug, want := range map[string]bool{
+		"untranslated": false,
+		"title-only":   false,
+		"partial":      true,
+		"complete":     true,
+	} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/"+slug, nil))
+		if note := strings.Contains(w.Body.String(), `class="original"`); note != want {
+			t.Errorf("/%s shows the note on the original: %v; want %v", slug, note, want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// tagFile is the name of the tag translation table in the content directory.
+const tagFile = "tags.txt"
+
+// tagLabels maps the tags of the articles to their Chinese labels. It is
+// read from a file with one tag per line, followed by its label.
+type tagLabels map[string]string
+
+// readTagLabels reads the tag translation table in the file of fsys. A
+// missing file is an empty table.
+func readTagLabels(fsys fs.FS, file string) (tagLabels, error) {
+	f, err := fsys.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tagLabels{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseTagLabels(f, file)
+}
+
+// parseTagLabels parses a tag translation table read from r. Blank lines
+// and lines starting with # are ignored.
+func parseTagLabels(r io.Reader, file string) (tagLabels, error) {
+	labels := make(tagLabels)
+	lines := make(map[string]int)
+	s := bufio.NewScanner(r)
+	for lineno := 1; s.Scan(); lineno++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: want tag and label", file, lineno)
+		}
+		tag, label := line[:i], strings.TrimSpace(line[i:])
+		if prev, ok := lines[tag]; ok {
+			return nil, fmt.Errorf("%s:%d: tag %s already labeled at line %d", file, lineno, tag, prev)
+		}
+		lines[tag] = lineno
+		labels[tag] = label
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// label returns the Chinese label of tag, or tag itself if it has none.
+func (l tagLabels) label(tag string) string {
+	if label, ok := l[tag]; ok {
+		return label
+	}
+	return tag
+}
+
+// A tagInfo describes a tag for the templates.
+type tagInfo struct {
+	Name  string // tag as written in the articles
+	Label string // Chinese label
+	Path  string // path of the tag page, including base
+
+	// Set in the tag cloud only.
+	Count int // number of articles
+	Size  int // from 1 to tagSizes, growing with Count
+}
+
+// tagSizes is the number of font sizes in the tag cloud.
+const tagSizes = 5
+
+// tag returns the description of the tag name.
+func (s *Server) tag(name string) tagInfo {
+	return tagInfo{
+		Name:  name,
+		Label: s.tagLabels.label(name),
+		Path:  s.cfg.BasePath + "/tag/" + name,
+	}
+}
+
+// tagCloud returns the tags of site, sorted by name, with their sizes.
+func (s *Server) tagCloud(site *site) []tagInfo {
+	max := 0
+	for _, docs := range site.docTags {
+		if len(docs) > max {
+			max = len(docs)
+		}
+	}
+	var cloud []tagInfo
+	for _, name := range site.tags {
+		t := s.tag(name)
+		t.Count = len(site.docTags[name])
+		t.Size = 1
+		if max > 1 {
+			t.Size += (tagSizes - 1) * (t.Count - 1) / (max - 1)
+		}
+		cloud = append(cloud, t)
+	}
+	return cloud
+}
+
+// tagData is the data of a tag page.
+type tagData struct {
+	Tag  tagInfo
+	Docs []*Doc
+}
+
+// newTagFeeds renders the feeds of each tag, in Chinese.
+func (s *Server) newTagFeeds() error {
+	site := s.sites[langZH]
+	s.tagFeeds = make(map[string]*feed)
+	for _, name := range site.tags {
+		title := s.cfg.FeedTitle + " - " + s.tagLabels.label(name)
+		f, err := s.newFeed(title, "/tag/"+name+".atom", site.docTags[name])
+		if err != nil {
+			return err
+		}
+		s.tagFeeds[name] = f
+	}
+	return nil
+}
+
+// serveTagFeed serves the feed of a tag if p is /tag/<name>.atom or
+// /tag/<name>.json, and reports whether it did.
+func (s *Server) serveTagFeed(w http.ResponseWriter, r *http.Request, p string) bool {
+	if !strings.HasPrefix(p, "/tag/") {
+		return false
+	}
+	name := p[len("/tag/"):]
+	ext := path.Ext(name)
+	f, ok := s.tagFeeds[strings.TrimSuffix(name, ext)]
+	if !ok {
+		return false
+	}
+	switch ext {
+	case ".atom":
+		f.serveAtom(w, r)
+	case ".json":
+		f.serveJSON(w, r)
+	default:
+		return false
+	}
+	return true
+}
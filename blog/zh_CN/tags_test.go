@@ -0,0 +1,83 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestTagTable checks that every tag of the articles has a Chinese label.
+func TestTagTable(t *testing.T) {
+	labels, err := readTagLabels(os.DirFS(contentDir), tagFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(contentDir, "*.article"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		for _, tag := range articleTags(t, file) {
+			if _, ok := labels[tag]; !ok {
+				t.Errorf("%s: tag %s has no label in %s", file, tag, tagFile)
+			}
+		}
+	}
+}
+
+// articleTags returns the tags on the Tags: line of the article in file.
+func articleTags(t *testing.T, file string) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "*") {
+			break
+		}
+		if strings.HasPrefix(line, "Tags:") {
+			var tags []string
+			for _, tag := range strings.Split(line[len("Tags:"):], ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+			return tags
+		}
+	}
+	return nil
+}
+
+func TestTagErrors(t *testing.T) {
+	for _, tt := range []struct {
+		table string
+		err   string
+	}{
+		{"go", "test:1: want tag and label"},
+		{"go Go\n\ngo 围棋", "test:3: tag go already labeled at line 1"},
+	} {
+		_, err := parseTagLabels(strings.NewReader(tt.table), "test")
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("parseTagLabels(%q) = %v; want %s", tt.table, err, tt.err)
+		}
+	}
+
+	labels, err := parseTagLabels(strings.NewReader("# comment\nio  I/O\ntour\tGo 之旅\n"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tag, want := range map[string]string{"io": "I/O", "tour": "Go 之旅", "gob": "gob"} {
+		if got := labels.label(tag); got != want {
+			t.Errorf("label(%q) = %q; want %q", tag, got, want)
+		}
+	}
+}
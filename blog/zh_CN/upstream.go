@@ -0,0 +1,196 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// upstreamFile is the name of the table of original URLs in the content
+// directory.
+const upstreamFile = "upstream.txt"
+
+// noUpstream is the URL of the articles that have no English original.
+const noUpstream = "-"
+
+// upstreams maps the slugs of articles to the URLs of their English
+// originals on the upstream blog. It is read from a file with one article
+// per line: its slug and the URL of its original, or - if it has none. The
+// original of the articles missing from the table is at their slug below
+// the UpstreamURL setting.
+type upstreams struct {
+	file  string
+	urls  map[string]string // slug to URL, empty if none
+	lines map[string]int    // line of each slug
+}
+
+// readUpstreams reads the table of original URLs in the file of fsys. A
+// missing file is an empty table.
+func readUpstreams(fsys fs.FS, file string) (*upstreams, error) {
+	f, err := fsys.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return parseUpstreams(strings.NewReader(""), file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseUpstreams(f, file)
+}
+
+// parseUpstreams parses a table of original URLs read from r. Blank lines
+// and lines starting with # are ignored.
+func parseUpstreams(r io.Reader, file string) (*upstreams, error) {
+	us := &upstreams{
+		file:  file,
+		urls:  make(map[string]string),
+		lines: make(map[string]int),
+	}
+	s := bufio.NewScanner(r)
+	for lineno := 1; s.Scan(); lineno++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			return nil, fmt.Errorf("%s:%d: want slug and URL", file, lineno)
+		}
+		slug, u := f[0], f[1]
+		if strings.ContainsAny(slug, "/.") {
+			return nil, fmt.Errorf("%s:%d: %s is not an article slug", file, lineno, slug)
+		}
+		if prev, ok := us.lines[slug]; ok {
+			return nil, fmt.Errorf("%s:%d: %s already mapped at line %d", file, lineno, slug, prev)
+		}
+		if u == noUpstream {
+			u = ""
+		} else if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+			return nil, fmt.Errorf("%s:%d: %s is not an absolute http or https URL", file, lineno, u)
+		}
+		us.lines[slug] = lineno
+		us.urls[slug] = u
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return us, nil
+}
+
+// original returns the URL of the English original of the article slug, or
+// "" if it has none, given the base URL of the upstream blog.
+func (us *upstreams) original(upstreamURL, slug string) string {
+	if u, ok := us.urls[slug]; ok {
+		return u
+	}
+	if upstreamURL == "" {
+		return ""
+	}
+	return upstreamURL + "/" + slug
+}
+
+// check reports the slugs of the table for which exists is false, and the
+// articles in docs that have the same original.
+func (us *upstreams) check(exists func(slug string) bool, docs []*Doc) error {
+	var errs []string
+	for slug := range us.urls {
+		if !exists(slug) {
+			errs = append(errs, fmt.Sprintf("%s:%d: unknown article %s", us.file, us.lines[slug], slug))
+		}
+	}
+	seen := make(map[string]*Doc)
+	for _, d := range docs {
+		if d.Original == "" {
+			continue
+		}
+		key := upstreamKey(d.Original)
+		if prev, ok := seen[key]; ok {
+			errs = append(errs, fmt.Sprintf("%s: articles %s and %s have the same original %s", us.file, prev.Path, d.Path, d.Original))
+			continue
+		}
+		seen[key] = d
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Strings(errs)
+	return fmt.Errorf("%s", strings.Join(errs, "\n"))
+}
+
+// upstreamKey returns the URL u without its scheme, query, fragment and
+// trailing slash, so that the links to a page of the upstream blog match
+// whichever way they are written.
+func upstreamKey(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	return strings.ToLower(pu.Host) + strings.TrimSuffix(pu.Path, "/")
+}
+
+// href matches the links of a rendered article.
+var href = regexp.MustCompile(`href="([^"]*)"`)
+
+// linkTranslations lists, for each untranslated article of the site, the
+// completely translated articles of the site on the same topic: first
+// those whose originals it links to, on the upstream blog, then those that
+// share its tags. The links of the articles may be the legacy URLs of the
+// redirect table.
+func (s *Server) linkTranslations(site *site) {
+	originals := make(map[string]*Doc)
+	for _, d := range site.docs {
+		if d.Original != "" {
+			originals[upstreamKey(d.Original)] = d
+		}
+	}
+	// lookup returns the article of the site at the URL u of the
+	// upstream blog.
+	lookup := func(u string) *Doc {
+		key := upstreamKey(u)
+		if d, ok := originals[key]; ok {
+			return d
+		}
+		pu, err := url.Parse(u)
+		if err != nil || pu.Host == "" {
+			return nil
+		}
+		if up, err := url.Parse(s.cfg.UpstreamURL); err != nil || !strings.EqualFold(up.Host, pu.Host) {
+			return nil
+		}
+		slug, ok := s.redirect.lookup(pu.Path, s.exists)
+		if !ok {
+			return nil
+		}
+		return site.docPaths["/"+slug]
+	}
+
+	for _, doc := range site.docs {
+		if doc.Status != untranslated {
+			continue
+		}
+		seen := map[*Doc]bool{doc: true}
+		add := func(d *Doc) {
+			if d != nil && d.Status == complete && !seen[d] {
+				seen[d] = true
+				doc.Translations = append(doc.Translations, d)
+			}
+		}
+		for _, m := range href.FindAllStringSubmatch(string(doc.HTML), -1) {
+			add(lookup(html.UnescapeString(m[1])))
+		}
+		for _, d := range doc.Related {
+			add(d)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/present"
+)
+
+// TestUpstreamTable checks that the originals of the blog are those of
+// articles.
+func TestUpstreamTable(t *testing.T) {
+	us, err := readUpstreams(os.DirFS(contentDir), upstreamFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists := func(slug string) bool {
+		_, err := os.Stat(filepath.Join(contentDir, slug+".article"))
+		return err == nil
+	}
+	if err := us.check(exists, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+const testUpstreams = `
+# comment
+package-names  https://blog.golang.org/package-names-in-go
+gophergala     -
+`
+
+func TestUpstreamOriginal(t *testing.T) {
+	us, err := parseUpstreams(strings.NewReader(testUpstreams), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		upstreamURL, slug string
+		url               string
+	}{
+		{"https://blog.golang.org", "package-names", "https://blog.golang.org/package-names-in-go"},
+		{"https://blog.golang.org", "gophergala", ""},
+		{"https://blog.golang.org", "go1.4", "https://blog.golang.org/go1.4"},
+		{"", "go1.4", ""},
+	} {
+		if u := us.original(tt.upstreamURL, tt.slug); u != tt.url {
+			t.Errorf("original(%q, %q) = %q; want %q", tt.upstreamURL, tt.slug, u, tt.url)
+		}
+	}
+
+	exists := func(slug string) bool { return slug == "package-names" }
+	docs := []*Doc{
+		{Path: "/a", Original: "https://blog.golang.org/a"},
+		{Path: "/b", Original: "http://blog.golang.org/a/"},
+	}
+	err = us.check(exists, docs)
+	want := "test: articles /a and /b have the same original http://blog.golang.org/a/\n" +
+		"test:4: unknown article gophergala"
+	if err == nil || err.Error() != want {
+		t.Errorf("check = %v; want %s", err, want)
+	}
+}
+
+func TestUpstreamErrors(t *testing.T) {
+	for _, tt := range []struct {
+		table string
+		err   string
+	}{
+		{"a", "test:1: want slug and URL"},
+		{"a/b https://blog.golang.org/a", "test:1: a/b is not an article slug"},
+		{"a /a", "test:1: /a is not an absolute http or https URL"},
+		{"a ftp://blog.golang.org/a", "test:1: ftp://blog.golang.org/a is not an absolute http or https URL"},
+		{"a -\n\na https://blog.golang.org/a", "test:3: a already mapped at line 1"},
+	} {
+		_, err := parseUpstreams(strings.NewReader(tt.table), "test")
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("parseUpstreams(%q) = %v; want %s", tt.table, err, tt.err)
+		}
+	}
+}
+
+func TestLinkTranslations(t *testing.T) {
+	rs, err := parseRedirects(strings.NewReader("/2013/05/go-11-is-released.html go-11-is-released"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := func(slug string, status transStatus, html string) *Doc {
+		return &Doc{
+			Doc:      &present.Doc{Title: slug},
+			Path:     "/" + slug,
+			Original: "https://blog.golang.org/" + slug,
+			Status:   status,
+			HTML:     template.HTML(html),
+		}
+	}
+	var (
+		released = doc("go-11-is-released", complete, "")
+		names    = doc("package-names", complete, "")
+		gobs     = doc("gobs-of-data", partial, "")
+		tagged   = doc("go1.4", complete, "")
+		article  = doc("go12", untranslated,
+			`<a href="http://blog.golang.org/2013/05/go-11-is-released.html">1.1</a>`+
+				`<a href="//blog.golang.org/package-names/">names</a>`+
+				`<a href="https://blog.golang.org/gobs-of-data">gobs</a>`+
+				`<a href="https://golang.org/package-names">not the blog</a>`)
+	)
+	docs := []*Doc{released, names, gobs, tagged, article}
+	s := &Server{
+		cfg:      Config{UpstreamURL: "https://blog.golang.org"},
+		redirect: rs,
+		sites:    map[string]*site{defaultLang: newSite("", docs)},
+	}
+	article.Related = []*Doc{names, tagged}
+	s.linkTranslations(s.sites[defaultLang])
+	if want := []*Doc{released, names, tagged}; !reflect.DeepEqual(article.Translations, want) {
+		var got []string
+		for _, d := range article.Translations {
+			got = append(got, d.Path)
+		}
+		t.Errorf("translations = %v; want %s, %s and %s", got, released.Path, names.Path, tagged.Path)
+	}
+	for _, d := range []*Doc{released, names, gobs, tagged} {
+		if d.Translations != nil {
+			t.Errorf("translated article %s has translations", d.Path)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/golang-china/golangdoc.translations/cmd
+
+go 1.22
@@ -0,0 +1,196 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// blogHost is the host of the Chinese blog.
+	blogHost = "blog.golang-china.appspot.com"
+
+	// upstreamBlogHost is the host of the English blog.
+	upstreamBlogHost = "blog.golang.org"
+)
+
+// upstreamHosts are the hosts of the English site.
+var upstreamHosts = map[string]bool{
+	"golang.org":     true,
+	"www.golang.org": true,
+}
+
+// A result is the outcome of checking a link.
+type result int
+
+const (
+	ok         result = iota // link resolves
+	external                 // link is outside the checked content
+	unverified               // link is to site content that is neither translated nor in GOROOT
+	broken                   // link does not resolve
+	upstream                 // link is to an English page that has a Chinese version here
+)
+
+// A source is a file containing links, along with the URL it is served at.
+type source struct {
+	file string
+	url  *url.URL
+}
+
+// check resolves the link found in src. For broken and upstream links the
+// message explains the problem.
+func (s *site) check(src *source, link string) (r result, msg string) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return broken, err.Error()
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return external, ""
+	}
+	u = src.url.ResolveReference(u)
+	switch {
+	case u.Host == "":
+		return s.checkSite(src, u)
+	case u.Host == blogHost:
+		if isSitePath(u.Path) {
+			return s.checkSite(src, u)
+		}
+		return s.checkBlog(u)
+	case upstreamHosts[u.Host]:
+		if r, _ := s.checkSite(src, u); r == ok && s.translated(u) {
+			return upstream, "Chinese version at " + u.Path + fragment(u)
+		}
+	case u.Host == upstreamBlogHost:
+		if a := s.blog[strings.Trim(u.Path, "/")]; a != nil && a.translated {
+			return upstream, "Chinese version at //" + blogHost + u.Path
+		}
+	}
+	return external, ""
+}
+
+// isSitePath reports whether p is a path of the documentation site, as
+// opposed to one of the blog.
+func isSitePath(p string) bool {
+	for _, prefix := range []string{"/doc/", "/pkg/", "/cmd/", "/ref/", "/src/", "/misc/", "/test/", "/blog/"} {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSite resolves a link to the documentation site.
+func (s *site) checkSite(src *source, u *url.URL) (result, string) {
+	p := u.Path
+	switch {
+	case strings.HasPrefix(p, "/pkg/"), strings.HasPrefix(p, "/cmd/"):
+		importPath := strings.Trim(strings.TrimPrefix(p, "/pkg"), "/")
+		if importPath == "" || importPath == "cmd" {
+			return ok, "" // package list
+		}
+		d := s.pkgs[importPath]
+		if d == nil {
+			return s.checkGOROOT(filepath.Join("src", filepath.FromSlash(importPath)))
+		}
+		if u.Fragment == "" || generatedAnchor(u.Fragment) || d.symbols[u.Fragment] {
+			return ok, ""
+		}
+		return broken, fmt.Sprintf("no symbol %s in %s", u.Fragment, d.file)
+	case strings.HasPrefix(p, "/blog/"):
+		return s.checkBlog(&url.URL{Path: strings.TrimPrefix(p, "/blog")})
+	}
+	pg := s.pages[p]
+	if pg == nil && p == src.url.Path {
+		// A fragment-only link in a page other than doc/zh_CN.
+		return ok, ""
+	}
+	if pg == nil {
+		return s.checkGOROOT(filepath.FromSlash(strings.TrimPrefix(p, "/")))
+	}
+	if u.Fragment == "" || pg.anchors[u.Fragment] {
+		return ok, ""
+	}
+	return broken, fmt.Sprintf("no anchor %s in %s", u.Fragment, pg.file)
+}
+
+// checkBlog resolves a link to the blog: either an article slug or a file
+// in the content directory.
+func (s *site) checkBlog(u *url.URL) (result, string) {
+	p := strings.Trim(u.Path, "/")
+	if p == "" || p == "index" || p == "feed.atom" || s.blog[p] != nil {
+		return ok, ""
+	}
+	if fileExists(filepath.Join(s.root, blogDir, filepath.FromSlash(p))) {
+		return ok, ""
+	}
+	return broken, "no blog article or file " + p
+}
+
+// checkGOROOT reports whether the named file, relative to GOROOT, exists in
+// the -goroot tree.
+func (s *site) checkGOROOT(name string) (result, string) {
+	if *goroot == "" {
+		return unverified, ""
+	}
+	name = strings.TrimSuffix(name, string(filepath.Separator))
+	if fileExists(filepath.Join(*goroot, name)) || fileExists(filepath.Join(*goroot, name+".html")) {
+		return ok, ""
+	}
+	return broken, "not found in translations or in " + *goroot
+}
+
+// translated reports whether the site content at u has a Chinese version.
+func (s *site) translated(u *url.URL) bool {
+	p := u.Path
+	switch {
+	case strings.HasPrefix(p, "/pkg/"), strings.HasPrefix(p, "/cmd/"):
+		d := s.pkgs[strings.Trim(strings.TrimPrefix(p, "/pkg"), "/")]
+		return d != nil && d.translated
+	case strings.HasPrefix(p, "/blog/"):
+		a := s.blog[strings.Trim(strings.TrimPrefix(p, "/blog"), "/")]
+		return a != nil && a.translated
+	}
+	pg := s.pages[p]
+	return pg != nil && pg.translated
+}
+
+// generatedAnchor reports whether the anchor on a package page is generated
+// by godoc rather than named after a declaration.
+func generatedAnchor(frag string) bool {
+	for _, prefix := range []string{"pkg-", "hdr-", "example_", "Example"} {
+		if strings.HasPrefix(frag, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func fragment(u *url.URL) string {
+	if u.Fragment == "" {
+		return ""
+	}
+	return "#" + u.Fragment
+}
+
+// docURL returns the URL at which the file, relative to root, is served.
+// Pages of the documentation site have URLs without a host, so that they
+// are told apart from links to the English site.
+func docURL(rel string) *url.URL {
+	rel = filepath.ToSlash(rel)
+	switch {
+	case strings.HasPrefix(rel, docDir+"/"):
+		return &url.URL{Path: "/doc/" + strings.TrimPrefix(rel, docDir+"/")}
+	case strings.HasPrefix(rel, blogDir+"/"):
+		slug := strings.TrimSuffix(strings.TrimPrefix(rel, blogDir+"/"), ".article")
+		return &url.URL{Scheme: "https", Host: blogHost, Path: "/" + slug}
+	case strings.HasPrefix(rel, "src/"):
+		return &url.URL{Path: "/pkg/" + path.Dir(strings.TrimPrefix(rel, "src/")) + "/"}
+	}
+	return &url.URL{Path: "/" + rel}
+}
@@ -0,0 +1,175 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Linkcheck checks the links between the translated documents, blog
+// articles and package documentation of this repository, without going
+// online.
+//
+// Usage:
+//
+//	linkcheck [flags]
+//
+// Linkcheck reads the HTML documents under doc/zh_CN, the articles under
+// blog/zh_CN/content and the doc_zh_CN.go files under src, and resolves the
+// links in them against that content: /doc/ paths and their anchors against
+// the HTML documents, /pkg/ paths and their anchors against the declarations
+// in doc_zh_CN.go, and blog paths against the article slugs and the files of
+// the blog content directory.
+//
+// It reports two kinds of problems:
+//
+//	broken    an internal link that does not resolve
+//	upstream  a link to an English page at golang.org or blog.golang.org
+//	          for which a Chinese version exists here
+//
+// Links to pages that are not translated, such as most of /src/, can only
+// be resolved by looking at a Go distribution; use -goroot to do so.
+// Otherwise they are counted as unverified, and listed with -v.
+//
+// Linkcheck exits with status 1 if it finds broken links.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	rootDir      = flag.String("root", ".", "root `dir`ectory of the translations tree")
+	goroot       = flag.String("goroot", "", "resolve untranslated pages against the Go distribution in `dir`")
+	showUpstream = flag.Bool("upstream", true, "report links to English pages that have a Chinese version")
+	verbose      = flag.Bool("v", false, "list unverified links")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: linkcheck [flags]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("linkcheck: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 0 {
+		usage()
+	}
+
+	s, err := loadSite(*rootDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var counts [upstream + 1]int
+	for _, fs := range []struct {
+		dir, ext string
+		links    func(line string) []string
+	}{
+		{docDir, ".html", htmlLinks},
+		{blogDir, ".article", articleLinks},
+		{"src", ".go", commentLinks},
+	} {
+		err := filepath.Walk(filepath.Join(*rootDir, fs.dir), func(name string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() || filepath.Ext(name) != fs.ext {
+				return err
+			}
+			if fs.ext == ".go" && fi.Name() != "doc_zh_CN.go" {
+				return nil
+			}
+			rel, err := filepath.Rel(*rootDir, name)
+			if err != nil {
+				return err
+			}
+			src := &source{file: rel, url: docURL(rel)}
+			return lines(name, func(lineno int, line string) {
+				for _, link := range fs.links(line) {
+					r, msg := s.check(src, link)
+					counts[r]++
+					report(src, lineno, link, r, msg)
+				}
+			})
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d ok, %d external, %d unverified, %d broken, %d upstream\n",
+		counts[ok], counts[external], counts[unverified], counts[broken], counts[upstream])
+	if counts[broken] > 0 {
+		os.Exit(1)
+	}
+}
+
+// report prints the result of checking link, if it is worth reporting.
+func report(src *source, lineno int, link string, r result, msg string) {
+	var kind string
+	switch {
+	case r == broken:
+		kind = "broken"
+	case r == upstream && *showUpstream:
+		kind = "upstream"
+	case r == unverified && *verbose:
+		kind = "unverified"
+	default:
+		return
+	}
+	if msg != "" {
+		msg = ": " + msg
+	}
+	fmt.Printf("%s:%d: %s %s%s\n", src.file, lineno, kind, link, msg)
+}
+
+var (
+	htmlLinkRE    = regexp.MustCompile(`\b(?:href|src)="([^"]*)"`)
+	articleLinkRE = regexp.MustCompile(`\[\[([^\]]+)\](?:\[[^\]]*\])?\]`)
+	articleCmdRE  = regexp.MustCompile(`^\.(?:link|image|iframe)\s+(\S+)`)
+	commentLinkRE = regexp.MustCompile(`https?://[!#-;=?-~]+`)
+)
+
+// htmlLinks returns the links in a line of an HTML document.
+func htmlLinks(line string) []string {
+	var links []string
+	for _, m := range htmlLinkRE.FindAllStringSubmatch(line, -1) {
+		if m[1] != "" && !strings.Contains(m[1], "{{") {
+			links = append(links, m[1])
+		}
+	}
+	return links
+}
+
+// articleLinks returns the links in a line of a present article.
+func articleLinks(line string) []string {
+	var links []string
+	if m := articleCmdRE.FindStringSubmatch(line); m != nil {
+		links = append(links, m[1])
+	}
+	for _, m := range articleLinkRE.FindAllStringSubmatch(line, -1) {
+		links = append(links, m[1])
+	}
+	return links
+}
+
+// commentLinks returns the URLs in a line of a Go source file.
+func commentLinks(line string) []string {
+	i := strings.Index(line, "//")
+	if i < 0 {
+		return nil
+	}
+	var links []string
+	for _, link := range commentLinkRE.FindAllString(line[i+2:], -1) {
+		// Trailing punctuation ends the sentence, not the URL.
+		link = strings.TrimRight(link, ".,;:)")
+		// Skip placeholders such as http://ipaddr:port.
+		if _, err := url.Parse(link); err == nil {
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,293 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// A site is an index of the translated content in the repository: the
+// documents under doc/zh_CN, the package documentation in src, and the
+// articles of the Chinese blog.
+type site struct {
+	root  string
+	pages map[string]*page    // by URL path, such as "/doc/effective_go.html" and "/ref/spec"
+	pkgs  map[string]*pkgDoc  // by import path, such as "net/http" and "cmd/go"
+	blog  map[string]*article // by slug, such as "package-names"
+}
+
+// A page is an HTML document under doc/zh_CN.
+type page struct {
+	file       string
+	anchors    map[string]bool
+	translated bool
+}
+
+// A pkgDoc is the translated documentation of a package.
+type pkgDoc struct {
+	file       string
+	symbols    map[string]bool
+	translated bool
+}
+
+// An article is a blog article.
+type article struct {
+	file       string
+	translated bool
+}
+
+const (
+	docDir    = "doc/zh_CN"
+	blogDir   = "blog/zh_CN/content"
+	docMarker = `<div class="english">`
+)
+
+// blogMarkers start the Chinese blocks of a blog article, in the syntax of
+// the blog and in the _tr includes of older articles. Like the blog, only
+// markers at column 0 count.
+var blogMarkers = []string{"#zh", ".html _tr/div_begin_zh_CN.html"}
+
+var (
+	metaRE   = regexp.MustCompile(`(?s)<!--(\{.*?\})-->`)
+	anchorRE = regexp.MustCompile(`\b(?:id|name)="([^"]+)"`)
+
+	// godoc gives each production of the EBNF blocks of a page an anchor
+	// named after it when it serves the page, such as #MethodName in the
+	// spec.
+	ebnfRE       = regexp.MustCompile(`(?s)<pre class="ebnf">(.*?)</pre>`)
+	productionRE = regexp.MustCompile(`(?m)^\s*(\pL[\pL\pN_]*)\s*=`)
+)
+
+// loadSite indexes the content of the translations tree at root.
+func loadSite(root string) (*site, error) {
+	s := &site{
+		root:  root,
+		pages: make(map[string]*page),
+		pkgs:  make(map[string]*pkgDoc),
+		blog:  make(map[string]*article),
+	}
+	if err := s.loadPages(); err != nil {
+		return nil, err
+	}
+	if err := s.loadPkgs(); err != nil {
+		return nil, err
+	}
+	if err := s.loadBlog(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadPages indexes the HTML documents under doc/zh_CN by the URL paths
+// they are served at: their file name below /doc/ and the "Path" given in
+// their metadata, if any.
+func (s *site) loadPages() error {
+	dir := filepath.Join(s.root, docDir)
+	return filepath.Walk(dir, func(name string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || filepath.Ext(name) != ".html" {
+			return err
+		}
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		src := string(b)
+		p := &page{
+			file:       name,
+			anchors:    make(map[string]bool),
+			translated: strings.Contains(src, docMarker),
+		}
+		for _, m := range anchorRE.FindAllStringSubmatch(src, -1) {
+			p.anchors[m[1]] = true
+		}
+		for _, m := range ebnfRE.FindAllStringSubmatch(src, -1) {
+			for _, prod := range productionRE.FindAllStringSubmatch(m[1], -1) {
+				p.anchors[prod[1]] = true
+			}
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		urlPath := "/doc/" + filepath.ToSlash(rel)
+		s.pages[urlPath] = p
+		if path.Base(urlPath) == "index.html" {
+			s.pages[path.Dir(urlPath)+"/"] = p
+		}
+		// Translated pages keep the original metadata after their own;
+		// only the first one is used.
+		if m := metaRE.FindStringSubmatch(src); m != nil {
+			var meta struct{ Path string }
+			if json.Unmarshal([]byte(m[1]), &meta) == nil && meta.Path != "" {
+				s.pages[meta.Path] = p
+			}
+		}
+		return nil
+	})
+}
+
+// loadPkgs indexes the doc_zh_CN.go files under src by import path.
+func (s *site) loadPkgs() error {
+	dir := filepath.Join(s.root, "src")
+	return filepath.Walk(dir, func(name string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || fi.Name() != "doc_zh_CN.go" {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filepath.Dir(name))
+		if err != nil {
+			return err
+		}
+		d, err := loadPkgDoc(name)
+		if err != nil {
+			return err
+		}
+		s.pkgs[filepath.ToSlash(rel)] = d
+		return nil
+	})
+}
+
+// loadPkgDoc reads the names declared in a doc_zh_CN.go file. These are the
+// anchors of the package page: "Name" for top-level declarations and
+// "Type.Name" for methods and struct fields.
+func loadPkgDoc(name string) (*pkgDoc, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	d := &pkgDoc{
+		file:       name,
+		symbols:    make(map[string]bool),
+		translated: hasChinese(f.Doc),
+	}
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				name = recvName(decl.Recv.List[0].Type) + "." + name
+			}
+			d.symbols[name] = true
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					d.symbols[spec.Name.Name] = true
+					var fields *ast.FieldList
+					switch t := spec.Type.(type) {
+					case *ast.StructType:
+						fields = t.Fields
+					case *ast.InterfaceType:
+						fields = t.Methods
+					}
+					if fields == nil {
+						continue
+					}
+					for _, f := range fields.List {
+						for _, id := range f.Names {
+							d.symbols[spec.Name.Name+"."+id.Name] = true
+						}
+					}
+				case *ast.ValueSpec:
+					for _, id := range spec.Names {
+						d.symbols[id.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return d, nil
+}
+
+// recvName returns the base type name of a method receiver.
+func recvName(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.StarExpr:
+		return recvName(x.X)
+	case *ast.Ident:
+		return x.Name
+	}
+	return ""
+}
+
+// hasChinese reports whether the comment group contains Chinese text.
+func hasChinese(g *ast.CommentGroup) bool {
+	if g == nil {
+		return false
+	}
+	for _, r := range g.Text() {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadBlog indexes the blog articles by slug. An article counts as
+// translated if it has a Chinese block, whether partly or completely
+// translated, as on the translations page of the blog; a translated title
+// alone does not count.
+func (s *site) loadBlog() error {
+	names, err := filepath.Glob(filepath.Join(s.root, blogDir, "*.article"))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		slug := strings.TrimSuffix(filepath.Base(name), ".article")
+		s.blog[slug] = &article{
+			file:       name,
+			translated: hasChineseBlock(string(b)),
+		}
+	}
+	return nil
+}
+
+// hasChineseBlock reports whether the article src has a Chinese block.
+func hasChineseBlock(src string) bool {
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		for _, m := range blogMarkers {
+			if line == m {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fileExists reports whether the named file exists.
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// lines calls fn for each line of the named file.
+func lines(name string, fn func(lineno int, line string)) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<20)
+	for lineno := 1; s.Scan(); lineno++ {
+		fn(lineno, s.Text())
+	}
+	return s.Err()
+}
@@ -0,0 +1,245 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// usedKeys returns the keys of the msg calls in the templates of tmplDir
+// and in the Go files of goDir, each with the position of one of its calls.
+func usedKeys(tmplDir, goDir string) (map[string]string, error) {
+	used := make(map[string]string)
+	files, err := filepath.Glob(filepath.Join(tmplDir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := templateKeys(used, file, string(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err = filepath.Glob(filepath.Join(goDir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				// A call of the msg method of the catalog.
+				sel, ok := n.Fun.(*ast.SelectorExpr)
+				if !ok || sel.Sel.Name != "msg" || len(n.Args) == 0 {
+					break
+				}
+				if key, ok := stringLit(n.Args[0]); ok {
+					add(used, key, fset.Position(n.Pos()).String())
+				}
+			case *ast.BasicLit:
+				// A template.
+				s, ok := stringLit(n)
+				if !ok || !strings.Contains(s, "{{") {
+					break
+				}
+				pos := fset.Position(n.Pos())
+				if err := templateKeys(used, pos.String(), s); err != nil {
+					// Not a template after all.
+					break
+				}
+			}
+			return true
+		})
+	}
+	return used, nil
+}
+
+// tourMsgRE matches the keys of the messages in the partials and the index
+// of the tour, which look them up with the msg filter of AngularJS, as in
+// {{'editor.run' | msg}}, and in its scripts, which call i18n.l('key').
+var tourMsgRE = regexp.MustCompile(`'([\w.-]+)'\s*\|\s*msg\b|\bi18n\.l\('([\w.-]+)'\)`)
+
+// tourKeys returns the keys of the messages that the tour in dir looks up,
+// in its index template, partials and scripts, each with the position of
+// one of its uses.
+func tourKeys(dir string) (map[string]string, error) {
+	used := make(map[string]string)
+	var files []string
+	for _, pattern := range []string{"template/*.tmpl", "static/partials/*.html", "static/js/*.js"} {
+		f, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f...)
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for i, line := range strings.Split(string(b), "\n") {
+			for _, m := range tourMsgRE.FindAllStringSubmatch(line, -1) {
+				add(used, m[1]+m[2], fmt.Sprintf("%s:%d", file, i+1))
+			}
+		}
+	}
+	return used, nil
+}
+
+// stringLit returns the value of the string literal x.
+func stringLit(x ast.Expr) (string, bool) {
+	lit, ok := x.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// add records that key is used at pos, if it is not recorded yet.
+func add(used map[string]string, key, pos string) {
+	if _, ok := used[key]; !ok {
+		used[key] = pos
+	}
+}
+
+// templateKeys adds the keys of the msg calls in the template text, read
+// from file, to used.
+func templateKeys(used map[string]string, file, text string) error {
+	t := parse.New(file)
+	t.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := t.Parse(text, "", "", trees); err != nil {
+		return err
+	}
+	for _, tree := range trees {
+		var walk func(parse.Node)
+		walk = func(n parse.Node) {
+			switch n := n.(type) {
+			case *parse.ListNode:
+				if n == nil {
+					return
+				}
+				for _, n := range n.Nodes {
+					walk(n)
+				}
+			case *parse.ActionNode:
+				walk(n.Pipe)
+			case *parse.TemplateNode:
+				if n.Pipe != nil {
+					walk(n.Pipe)
+				}
+			case *parse.IfNode:
+				walk(&n.BranchNode)
+			case *parse.RangeNode:
+				walk(&n.BranchNode)
+			case *parse.WithNode:
+				walk(&n.BranchNode)
+			case *parse.BranchNode:
+				walk(n.Pipe)
+				walk(n.List)
+				walk(n.ElseList)
+			case *parse.PipeNode:
+				for _, c := range n.Cmds {
+					walk(c)
+				}
+			case *parse.CommandNode:
+				if len(n.Args) > 1 {
+					id, ok1 := n.Args[0].(*parse.IdentifierNode)
+					key, ok2 := n.Args[1].(*parse.StringNode)
+					if ok1 && ok2 && id.Ident == "msg" {
+						pos, _ := tree.ErrorContext(n)
+						add(used, key.Text, pos)
+					}
+				}
+				for _, arg := range n.Args {
+					walk(arg)
+				}
+			}
+		}
+		walk(tree.Root)
+	}
+	return nil
+}
+
+// A message is a line of a catalog.
+type message struct {
+	key, text string
+	line      int
+}
+
+// A catalog holds the messages of a locale.
+type catalog struct {
+	file string
+	list []message         // in the order of the file
+	text map[string]string // text of each key
+}
+
+// readCatalog reads the catalog in file, in which each line is the key of
+// a message followed by its text. Blank lines and lines starting with #
+// are ignored.
+func readCatalog(file string) (*catalog, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c := &catalog{file: file, text: make(map[string]string)}
+	s := bufio.NewScanner(f)
+	for lineno := 1; s.Scan(); lineno++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: want key and text", file, lineno)
+		}
+		m := message{key: line[:i], text: strings.TrimSpace(line[i:]), line: lineno}
+		if _, ok := c.text[m.key]; ok {
+			return nil, fmt.Errorf("%s:%d: message %s already defined", file, lineno, m.key)
+		}
+		c.list = append(c.list, m)
+		c.text[m.key] = m.text
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return c, nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
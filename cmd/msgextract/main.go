@@ -0,0 +1,168 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Msgextract lists the messages of the blog templates, or of the tour, that
+// the catalog of a locale lacks, so that they can be translated.
+//
+// Usage:
+//
+//	msgextract [flags] locale
+//
+// Msgextract is installed from the cmd module, with go install ./msgextract
+// in the cmd directory, and run from the root of the repository, where the
+// default of -blog is.
+//
+// The templates look up the text around the articles by key, with the msg
+// function, in the message catalog of the locale of the blog:
+//
+//	<h2>{{msg "article.related"}}</h2>
+//
+// The catalog of a locale is the file <locale>.txt in the messages
+// subdirectory of the template directory, with one message per line: its
+// key, followed by its text. The messages missing from it are shown in
+// English, from en.txt.
+//
+// Msgextract finds the keys of the msg calls in the templates, and in the
+// Go files of the blog, including the templates in their strings. It
+// prints the messages that the catalog of locale lacks, which are those
+// used by the templates or defined by the catalog of the base locale, with
+// their text in the base locale, in the format of the catalog:
+//
+//	msgextract -base zh-CN zh-TW >> blog/zh_CN/template/messages/zh-TW.txt
+//
+// It also reports the keys that the templates use and that the base
+// catalog lacks, and the keys of the catalog of locale that are neither
+// used nor in the base catalog, and then exits with status 1.
+//
+// With -tour, msgextract does the same for the pages of the tour, which
+// AngularJS renders in the browser: the index template, the partials and
+// the scripts look up their messages by key, with the msg filter or the
+// i18n service,
+//
+//	<a id="run" ng-click="run()">{{'editor.run' | msg}}</a>
+//
+// in the catalogs of the template directory of the tour. The text of these
+// messages is plain text, not HTML. The tour cannot read the catalogs;
+// with -json, msgextract writes the messages of locale, and those it lacks
+// in the base locale, as the JSON object that the tour loads:
+//
+//	msgextract -tour tour/zh_CN -json zh-CN > tour/zh_CN/static/messages/zh-CN.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+var (
+	base    = flag.String("base", "en", "`locale` whose messages are translated")
+	blogDir = flag.String("blog", "blog/zh_CN", "blog `directory`, holding the Go files of the blog server")
+	tourDir = flag.String("tour", "", "tour `directory`, holding the template and static directories of the tour, whose messages to extract instead of those of the blog")
+	tmplDir = flag.String("template", "", "template `directory` (default the template subdirectory of -blog, or of -tour)")
+	asJSON  = flag.Bool("json", false, "write the messages of locale as a JSON object, for the tour, rather than those it lacks")
+)
+
+// messageDir is the directory of the catalogs in the template directory.
+const messageDir = "messages"
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: msgextract [flags] locale\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("msgextract: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+	}
+	locale := flag.Arg(0)
+	if *tmplDir == "" {
+		dir := *blogDir
+		if *tourDir != "" {
+			dir = *tourDir
+		}
+		*tmplDir = filepath.Join(dir, "template")
+	}
+
+	exit := 0
+	var used map[string]string
+	var err error
+	if *tourDir != "" {
+		used, err = tourKeys(*tourDir)
+	} else {
+		used, err = usedKeys(*tmplDir, *blogDir)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	baseMsgs, err := readCatalog(filepath.Join(*tmplDir, messageDir, *base+".txt"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	msgs, err := readCatalog(filepath.Join(*tmplDir, messageDir, locale+".txt"))
+	if os.IsNotExist(err) {
+		msgs = new(catalog) // a new locale
+	} else if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, key := range sortedKeys(used) {
+		if _, ok := baseMsgs.text[key]; !ok {
+			log.Printf("%s: message %s is not in %s.txt", used[key], key, *base)
+			exit = 1
+		}
+	}
+	for _, m := range msgs.list {
+		if _, ok := baseMsgs.text[m.key]; !ok && used[m.key] == "" {
+			log.Printf("%s:%d: message %s is neither used nor in %s.txt", msgs.file, m.line, m.key, *base)
+			exit = 1
+		}
+	}
+
+	if *asJSON {
+		if err := writeJSON(os.Stdout, baseMsgs, msgs); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(exit)
+	}
+
+	var missing []message
+	for _, m := range baseMsgs.list {
+		if _, ok := msgs.text[m.key]; !ok {
+			missing = append(missing, m)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("\n# Messages of %s.txt missing from %s.txt, to translate.\n", *base, locale)
+		for _, m := range missing {
+			fmt.Printf("%s %s\n", m.key, m.text)
+		}
+	}
+	os.Exit(exit)
+}
+
+// writeJSON writes to w the messages of msgs, and those of base that msgs
+// lacks, as a JSON object from key to text.
+func writeJSON(w io.Writer, base, msgs *catalog) error {
+	text := make(map[string]string)
+	for _, m := range base.list {
+		text[m.key] = m.text
+	}
+	for _, m := range msgs.list {
+		text[m.key] = m.text
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	return enc.Encode(text)
+}
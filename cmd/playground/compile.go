@@ -0,0 +1,292 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// An Event is output of a snippet. The front end writes its Message after
+// waiting for Delay since the previous event.
+type Event struct {
+	Message string
+	Kind    string // "stdout" or "stderr"
+	Delay   time.Duration
+}
+
+type compileResponse struct {
+	Errors string
+	Events []Event
+	Status int // exit status of the snippet
+}
+
+// sem limits the number of snippets built and run at once.
+var sem = make(chan bool, runtime.NumCPU())
+
+// compileHandler builds and runs the snippet in the body form value.
+func compileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	body, ok := snippet(w, r)
+	if !ok {
+		return
+	}
+	sem <- true
+	resp, err := compileAndRun(body)
+	<-sem
+	if err != nil {
+		log.Printf("compile: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("compile: %v", err)
+	}
+}
+
+// compileAndRun builds and runs the snippet body. Errors in the snippet
+// are reported in the response; the error is for failures of the server.
+func compileAndRun(body string) (*compileResponse, error) {
+	dir, err := ioutil.TempDir("", "playground")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "prog.go")
+	if err := ioutil.WriteFile(src, []byte(body), 0644); err != nil {
+		return nil, err
+	}
+
+	exe := filepath.Join(dir, "prog")
+	args := []string{"build", "-o", exe}
+	if *fakeTime {
+		args = append(args, "-tags", "faketime")
+	}
+	cmd := exec.Command("go", append(args, "prog.go")...)
+	cmd.Dir = dir
+	// Snippets may only import what is installed.
+	cmd.Env = append(os.Environ(), "GOPROXY=off")
+	out, err := run(cmd, *buildTimeout, nil)
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok && err != errTimeout {
+			return nil, err
+		}
+		// Report errors relative to the snippet.
+		msg := strings.Replace(string(out), dir+string(filepath.Separator), "", -1)
+		msg = strings.Replace(msg, "# command-line-arguments\n", "", 1)
+		msg = strings.Replace(msg, "./prog.go", "prog.go", -1)
+		if err == errTimeout {
+			msg += "\nbuild timed out"
+		}
+		return &compileResponse{Errors: msg}, nil
+	}
+
+	resp := new(compileResponse)
+	cmd = exec.Command(exe)
+	cmd.Dir = dir
+	cmd.Env = []string{"HOME=" + dir}
+	kill := func() { cmd.Process.Kill() }
+	stdout := &limitedBuffer{limit: *outputLimit, exceed: kill}
+	stderr := &limitedBuffer{limit: *outputLimit, exceed: kill}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	mem := &memWatch{limit: *memLimit << 20, done: make(chan bool)}
+	_, err = run(cmd, *runTimeout, mem.start)
+	mem.stop()
+	resp.Events = events(stdout.Bytes(), stderr.Bytes())
+	switch {
+	case stdout.exceeded() || stderr.exceeded():
+		resp.Errors = "output too large"
+	case mem.exceeded():
+		resp.Errors = "out of memory"
+	case err == errTimeout:
+		resp.Errors = "process took too long"
+	case err != nil:
+		e, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, err
+		}
+		resp.Status = 1
+		if status, ok := e.Sys().(interface {
+			ExitStatus() int
+		}); ok {
+			resp.Status = status.ExitStatus()
+		}
+		resp.Events = append(resp.Events, Event{
+			Message: fmt.Sprintf("\nProgram exited: %v.\n", err),
+			Kind:    "stderr",
+		})
+	}
+	return resp, nil
+}
+
+var errTimeout = errors.New("timed out")
+
+// run runs cmd, killing it after timeout. If cmd.Stdout is not set, run
+// returns the combined output of cmd. The function started, if not nil, is called
+// with the process once it is started.
+func run(cmd *exec.Cmd, timeout time.Duration, started func(*os.Process)) ([]byte, error) {
+	var buf bytes.Buffer
+	if cmd.Stdout == nil {
+		cmd.Stdout = &buf
+		cmd.Stderr = &buf
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if started != nil {
+		started(cmd.Process)
+	}
+	t := time.AfterFunc(timeout, func() { cmd.Process.Kill() })
+	err := cmd.Wait()
+	if !t.Stop() {
+		return buf.Bytes(), errTimeout
+	}
+	return buf.Bytes(), err
+}
+
+// A limitedBuffer is a buffer that drops what is written to it beyond its
+// limit, and calls exceed the first time it does.
+type limitedBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	limit  int
+	exceed func()
+	over   bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.buf.Len()+len(p) > b.limit {
+		b.buf.Write(p[:b.limit-b.buf.Len()])
+		if !b.over {
+			b.over = true
+			b.exceed()
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.over
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
+
+// A memWatch kills a process that uses more than limit bytes of memory.
+type memWatch struct {
+	limit int64
+	done  chan bool // closed when the process is done
+	over  int32     // set atomically when the limit is exceeded
+}
+
+// stop stops watching the process.
+func (m *memWatch) stop() {
+	close(m.done)
+}
+
+// exceeded reports whether the process was killed for using too much
+// memory.
+func (m *memWatch) exceeded() bool {
+	return atomic.LoadInt32(&m.over) != 0
+}
+
+// In fake time mode, the runtime writes the output of a program in records
+// made of a header, the time of the write in nanoseconds and the length of
+// the data, both big-endian, followed by the data.
+const (
+	playbackHeader    = "\x00\x00PB"
+	playbackHeaderLen = len(playbackHeader) + 8 + 4
+)
+
+// fakeStart is the time at which the fake clock starts, in nanoseconds
+// since the Unix epoch: 2009-11-10 23:00:00 UTC.
+const fakeStart = 1257894000000000000
+
+// A record is output written at some time.
+type record struct {
+	kind string
+	when int64
+	data []byte
+}
+
+// events returns the output of a program as events, in the order it was
+// written. Output that is not in playback records, as that of programs run
+// without a fake clock, is a single event of each kind.
+func events(stdout, stderr []byte) []Event {
+	recs := append(records("stdout", stdout), records("stderr", stderr)...)
+	sort.Stable(byWhen(recs))
+	var evs []Event
+	last := int64(fakeStart)
+	for _, r := range recs {
+		if len(evs) > 0 && r.when == last && evs[len(evs)-1].Kind == r.kind {
+			evs[len(evs)-1].Message += string(r.data)
+			continue
+		}
+		delay := r.when - last
+		if delay < 0 {
+			delay = 0
+		}
+		evs = append(evs, Event{Message: string(r.data), Kind: r.kind, Delay: time.Duration(delay)})
+		last = r.when
+	}
+	return evs
+}
+
+// records splits the output b in playback records. Output that does not
+// start with a record is a single record written at fakeStart. A record
+// cut short by the output limit keeps the data it has.
+func records(kind string, b []byte) []record {
+	var recs []record
+	for len(b) > 0 {
+		if len(b) < playbackHeaderLen || string(b[:len(playbackHeader)]) != playbackHeader {
+			recs = append(recs, record{kind, fakeStart, b})
+			break
+		}
+		when := int64(binary.BigEndian.Uint64(b[len(playbackHeader):]))
+		n := int(binary.BigEndian.Uint32(b[len(playbackHeader)+8:]))
+		b = b[playbackHeaderLen:]
+		if n > len(b) {
+			n = len(b)
+		}
+		recs = append(recs, record{kind, when, b[:n]})
+		b = b[n:]
+	}
+	return recs
+}
+
+// byWhen sorts records by the time they were written.
+type byWhen []record
+
+func (s byWhen) Len() int           { return len(s) }
+func (s byWhen) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byWhen) Less(i, j int) bool { return s[i].when < s[j].when }
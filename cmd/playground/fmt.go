@@ -0,0 +1,36 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"go/format"
+	"log"
+	"net/http"
+)
+
+type fmtResponse struct {
+	Body  string
+	Error string
+}
+
+// fmtHandler formats the snippet in the body form value with gofmt.
+func fmtHandler(w http.ResponseWriter, r *http.Request) {
+	body, ok := snippet(w, r)
+	if !ok {
+		return
+	}
+	var resp fmtResponse
+	out, err := format.Source([]byte(body))
+	if err != nil {
+		resp.Error = "prog.go:" + err.Error()
+	} else {
+		resp.Body = string(out)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("fmt: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Playground is a local backend for the Go playground. It builds and runs
+// the snippets of the blog articles, the tour and the talks with the local
+// Go toolchain, so that they run offline and on mirrors that cannot reach
+// play.golang.org.
+//
+// Usage:
+//
+//	playground [flags]
+//
+// Playground serves the two requests of the playground front end, with the
+// snippet in the body form value:
+//
+//	/compile  build and run the snippet; the response is the JSON object
+//	          {"Errors": "...", "Events": [{"Message", "Kind", "Delay"}]}
+//	/fmt      format the snippet; the response is {"Body": "...", "Error": "..."}
+//
+// The front end sends these requests to the server of the page, so the
+// blog, tour and talk servers share a backend by forwarding /compile and
+// /fmt to it; the blog does so with its -play flag.
+//
+// Snippets are built and run in a temporary directory, one at a time per
+// CPU, within the limits set by the flags: the build and the run time out,
+// the program is killed when it uses too much memory (on Linux only) or
+// writes too much output. These limits keep runaway snippets from taking
+// the machine down, but they are no sandbox: the programs run with the
+// privileges of the server, and may use the network and file system. Do
+// not serve arbitrary users with it. For this reason, playground only
+// listens on a loopback address, such as localhost, unless -public allows
+// others, and it refuses snippets larger than 64 KB.
+//
+// With -faketime, which is the default, snippets are built with the fake
+// clock of the runtime, as on play.golang.org: time starts at 2009-11-10
+// 23:00:00 UTC and sleeping advances it at once. The output records when it
+// was written, and the front end plays it back with the same delays, so
+// that programs that sleep for minutes run instantly. This needs Go 1.14 or
+// later; older toolchains run snippets in real time.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+var (
+	httpAddr     = flag.String("http", "localhost:8081", "HTTP listen `address`")
+	buildTimeout = flag.Duration("buildtimeout", 30*time.Second, "maximum build time")
+	runTimeout   = flag.Duration("timeout", 5*time.Second, "maximum run time, in real time")
+	memLimit     = flag.Int64("mem", 100, "maximum memory of a running snippet, in `MB`")
+	outputLimit  = flag.Int("output", 1<<20, "maximum output of a snippet, in `bytes`")
+	fakeTime     = flag.Bool("faketime", true, "run snippets with a fake clock")
+	public       = flag.Bool("public", false, "allow a -http address that is not a loopback address, serving other machines")
+)
+
+// maxSnippetSize is the size limit of the body of a request, as on
+// play.golang.org.
+const maxSnippetSize = 64 << 10
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: playground [flags]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("playground: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 0 {
+		usage()
+	}
+
+	if !*public && !loopback(*httpAddr) {
+		log.Fatalf("%s is not a loopback address; snippets run unsandboxed, so serving other machines needs -public", *httpAddr)
+	}
+	http.HandleFunc("/compile", compileHandler)
+	http.HandleFunc("/fmt", fmtHandler)
+	log.Printf("serving on %s", *httpAddr)
+	log.Fatal(http.ListenAndServe(*httpAddr, nil))
+}
+
+// loopback reports whether the listen address addr only accepts
+// connections from this machine: whether its host is, or only resolves to,
+// loopback addresses. An empty host listens on every interface.
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback()
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !ip.IsLoopback() {
+			return false
+		}
+	}
+	return true
+}
+
+// snippet returns the snippet in the body form value of r, reading at most
+// maxSnippetSize bytes of its body. If it cannot, it replies to the request
+// with an error and returns false.
+func snippet(w http.ResponseWriter, r *http.Request) (string, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSnippetSize)
+	if err := r.ParseForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "snippet too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return "", false
+	}
+	return r.FormValue("body"), true
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// memPollInterval is how often the memory of a process is measured.
+const memPollInterval = 50 * time.Millisecond
+
+// start starts watching the resident memory of process p, as reported by
+// the proc file system, until m is stopped.
+func (m *memWatch) start(p *os.Process) {
+	go func() {
+		t := time.NewTicker(memPollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-t.C:
+			}
+			rss, ok := residentMemory(p.Pid)
+			if !ok {
+				return
+			}
+			if rss > m.limit {
+				atomic.StoreInt32(&m.over, 1)
+				p.Kill()
+				return
+			}
+		}
+	}()
+}
+
+// residentMemory returns the resident memory of the process pid, in bytes.
+func residentMemory(pid int) (int64, bool) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if strings.HasPrefix(s.Text(), "VmRSS:") {
+			var kb int64
+			if _, err := fmt.Sscanf(s.Text(), "VmRSS: %d kB", &kb); err != nil {
+				return 0, false
+			}
+			return kb << 10, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,12 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !linux
+
+package main
+
+import "os"
+
+// start does nothing: the memory of processes is only limited on Linux.
+func (m *memWatch) start(p *os.Process) {}
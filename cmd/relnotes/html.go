@@ -0,0 +1,235 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlMeta    = regexp.MustCompile(`(?s)<!--(\{.*?\})-->`)
+	htmlTag     = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)([^>]*)>`)
+	htmlPkgLink = regexp.MustCompile(`href="/pkg/([a-z0-9_./]+?)/?(?:#[^"]*)?"`)
+)
+
+// blockTags lists the elements that parseHTML treats as separate entries.
+// Other elements are considered part of the enclosing block.
+var blockTags = map[string]bool{
+	"blockquote": true,
+	"div":        true,
+	"dl":         true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"li":         true,
+	"ol":         true,
+	"p":          true,
+	"pre":        true,
+	"table":      true,
+	"ul":         true,
+}
+
+// A block is a top-level element of an HTML document.
+type block struct {
+	tag     string // element name, in lower case
+	attr    string // attributes of the start tag
+	html    string // the whole element
+	inner   string // content of the element
+	english bool   // whether the element is inside <div class="english">
+}
+
+const englishDiv = `<div class="english">`
+
+// scanBlocks splits src into its top-level block elements. The contents of
+// <div class="english"> elements are returned as blocks of their own, marked
+// as English. Text outside of block elements is ignored.
+func scanBlocks(src string) []block {
+	var (
+		blocks []block
+		stack  []string // open block elements
+		start  int      // offset of the current top-level element
+		inner  int      // offset of its content
+		attr   string
+		base   int // len(stack) at which elements are top-level
+	)
+	for _, m := range htmlTag.FindAllStringSubmatchIndex(src, -1) {
+		closing := m[3] > m[2]
+		tag := strings.ToLower(src[m[4]:m[5]])
+		if !blockTags[tag] {
+			continue
+		}
+		if !closing {
+			if len(stack) == 0 && src[m[0]:m[1]] == englishDiv {
+				stack = append(stack, "english")
+				base = 1
+				continue
+			}
+			// Paragraphs and list items may be left unclosed.
+			if (tag == "p" || tag == "li") && len(stack) > base && stack[len(stack)-1] == tag {
+				if len(stack) == base+1 {
+					blocks = append(blocks, newBlock(tag, attr, src[start:m[0]], src[inner:m[0]], base > 0))
+				}
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == base {
+				start, inner, attr = m[0], m[1], src[m[6]:m[7]]
+			}
+			stack = append(stack, tag)
+			continue
+		}
+		// Close the innermost matching element, along with any unclosed
+		// elements inside it.
+		i := len(stack) - 1
+		for i >= 0 && stack[i] != tag && !(tag == "div" && stack[i] == "english") {
+			i--
+		}
+		if i < 0 {
+			continue // stray end tag
+		}
+		if stack[i] == "english" {
+			stack, base = stack[:0], 0
+			continue
+		}
+		if i == base {
+			blocks = append(blocks, newBlock(tag, attr, src[start:m[1]], src[inner:m[0]], base > 0))
+		}
+		stack = stack[:i]
+	}
+	return blocks
+}
+
+func newBlock(tag, attr, html, inner string, english bool) block {
+	return block{
+		tag:     tag,
+		attr:    attr,
+		html:    strings.TrimSpace(html),
+		inner:   strings.TrimSpace(inner),
+		english: english,
+	}
+}
+
+// isHeading reports whether b is a section heading.
+func (b block) isHeading() bool {
+	return b.tag == "h2" || b.tag == "h3" || b.tag == "h4"
+}
+
+// id returns the value of the id attribute of b.
+func (b block) id() string {
+	const key = `id="`
+	i := strings.Index(b.attr, key)
+	if i < 0 {
+		return ""
+	}
+	s := b.attr[i+len(key):]
+	if j := strings.Index(s, `"`); j >= 0 {
+		s = s[:j]
+	}
+	return s
+}
+
+// flattenLists replaces list blocks by the list items inside them, so that
+// each item becomes an entry of its own.
+func flattenLists(blocks []block) []block {
+	var out []block
+	for _, b := range blocks {
+		if b.tag != "ul" && b.tag != "ol" {
+			out = append(out, b)
+			continue
+		}
+		for _, li := range scanBlocks(b.inner) {
+			if li.tag != "li" {
+				continue
+			}
+			li.english = li.english || b.english
+			li.html = "<p>\n" + li.inner + "\n</p>"
+			out = append(out, li)
+		}
+	}
+	return out
+}
+
+// parseHTML parses release notes in the HTML format of the go1.x.html
+// pages. If the page is bilingual, that is, it contains English text inside
+// <div class="english"> elements, each English block is paired with the
+// Chinese blocks that follow it.
+func parseHTML(src string) (*Notes, error) {
+	n := new(Notes)
+	var metas []struct{ Title, Path string }
+	for _, m := range htmlMeta.FindAllStringSubmatch(src, -1) {
+		var meta struct{ Title, Path string }
+		if err := json.Unmarshal([]byte(m[1]), &meta); err != nil {
+			return nil, err
+		}
+		metas = append(metas, meta)
+	}
+	src = htmlMeta.ReplaceAllString(src, "")
+	bilingual := strings.Contains(src, englishDiv)
+	switch {
+	case len(metas) == 1:
+		n.Title, n.Path = metas[0].Title, metas[0].Path
+	case len(metas) > 1:
+		// Translated pages keep the original header after the Chinese one.
+		n.TitleZH = metas[0].Title
+		n.Title, n.Path = metas[1].Title, metas[1].Path
+	}
+
+	var (
+		sec     *Section
+		pending []*Entry // English entries awaiting their translation
+		next    int      // index in pending of the next untranslated entry
+		wasEN   bool     // whether the previous block was English
+	)
+	for _, b := range flattenLists(scanBlocks(src)) {
+		if strings.Contains(b.attr, `class="relnote-`) {
+			// Status notes added by render end the preceding entry.
+			wasEN = false
+			continue
+		}
+		english := b.english || !bilingual
+		if english && !wasEN {
+			pending, next = nil, 0
+		}
+		wasEN = english
+
+		if b.isHeading() {
+			if english {
+				sec = &Section{ID: b.id(), English: b.inner}
+				n.Sections = append(n.Sections, sec)
+			} else if sec != nil && sec.Chinese == "" {
+				sec.Chinese, sec.IDZH = b.inner, b.id()
+			}
+			continue
+		}
+		if sec == nil {
+			sec = new(Section)
+			n.Sections = append(n.Sections, sec)
+		}
+		if english {
+			e := &Entry{English: b.html}
+			for _, m := range htmlPkgLink.FindAllStringSubmatch(b.html, -1) {
+				e.Packages = appendUnique(e.Packages, m[1])
+			}
+			sec.Entries = append(sec.Entries, e)
+			pending = append(pending, e)
+			continue
+		}
+		switch {
+		case next < len(pending):
+			pending[next].Chinese = b.html
+			next++
+		case len(pending) > 0:
+			// More translated than original blocks; keep them together
+			// with the last one.
+			e := pending[len(pending)-1]
+			e.Chinese += "\n\n" + b.html
+		default:
+			sec.Entries = append(sec.Entries, &Entry{Chinese: b.html})
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Relnotes generates bilingual release notes pages for doc/zh_CN.
+//
+// Usage:
+//
+//	relnotes [flags] file
+//
+// The input file is either a release notes page such as doc/zh_CN/go1.4.html,
+// translated or not, or a plain text list of changes in the format of
+// doc/zh_CN/go1.5.txt:
+//
+//	Tools:
+//
+//	cmd/go: add -run flag to go generate (https://golang.org/cl/9005)
+//
+// Relnotes splits the input into entries, links each entry to the translated
+// documentation of the packages it refers to, and writes a page in which
+// every English entry is followed by its translation and a note on the
+// translation status of the entry and of the package documentation.
+//
+// The flags are:
+//
+//	-o file
+//		write the page to file instead of standard output
+//	-merge file
+//		copy the translations of a previously generated page; the output
+//		file itself may be given to regenerate it in place
+//	-root dir
+//		root of the translations tree, used to find doc_zh_CN.go files
+//		(default ".")
+//	-title title, -path path
+//		title and URL path of the page, for text input
+//	-json
+//		write the entries as JSON, indexed by package, instead of HTML
+//
+// A summary of the translation status is printed to standard error.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	output   = flag.String("o", "", "output `file` (default standard output)")
+	mergeArg = flag.String("merge", "", "copy translations from previously generated `file`")
+	rootDir  = flag.String("root", ".", "root `dir`ectory of the translations tree")
+	titleArg = flag.String("title", "", "page title for text input")
+	pathArg  = flag.String("path", "", "page URL path for text input")
+	jsonOut  = flag.Bool("json", false, "write entries as JSON")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: relnotes [flags] file\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("relnotes: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+	}
+
+	n, err := parseFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *mergeArg != "" {
+		old, err := parseFile(*mergeArg)
+		if err != nil && !os.IsNotExist(err) {
+			log.Fatal(err)
+		}
+		if old != nil {
+			n.Merge(old)
+		}
+	}
+	if err := linkDocs(n, *rootDir); err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if *jsonOut {
+		err = writeJSON(&buf, n)
+	} else {
+		err = render(&buf, n)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+	} else {
+		err = ioutil.WriteFile(*output, buf.Bytes(), 0666)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	printSummary(n)
+}
+
+// parseFile parses the release notes in the named file, choosing the format
+// by the file name extension.
+func parseFile(name string) (*Notes, error) {
+	if filepath.Ext(name) == ".txt" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		n, err := parseText(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		n.Title, n.Path = textTitle(name)
+		return n, nil
+	}
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	n, err := parseHTML(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return n, nil
+}
+
+// textTitle returns the title and URL path for a text file of release notes.
+// Unless set by flags, they are derived from the file name: go1.5.txt is
+// titled "Go 1.5 Release Notes" at /doc/go1.5.
+func textTitle(name string) (title, path string) {
+	base := strings.TrimSuffix(filepath.Base(name), ".txt")
+	title, path = *titleArg, *pathArg
+	if title == "" {
+		title = "Go " + strings.TrimPrefix(base, "go") + " Release Notes"
+	}
+	if path == "" {
+		path = "/doc/" + base
+	}
+	return title, path
+}
+
+// writeJSON writes n as JSON, together with an index of its entries by
+// package.
+func writeJSON(buf *bytes.Buffer, n *Notes) error {
+	b, err := json.MarshalIndent(struct {
+		*Notes
+		Packages map[string][]*Entry
+	}{n, n.ByPackage()}, "", "\t")
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// printSummary prints the number of translated entries and package
+// documentation links to standard error.
+func printSummary(n *Notes) {
+	var entries, translated, docs, docsTranslated int
+	for _, e := range n.Entries() {
+		if e.English == "" {
+			continue
+		}
+		entries++
+		if e.Translated() {
+			translated++
+		}
+		for _, d := range e.Docs {
+			docs++
+			if d.Translated {
+				docsTranslated++
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d/%d entries translated, %d/%d package links translated\n",
+		n.Path, translated, entries, docsTranslated, docs)
+}
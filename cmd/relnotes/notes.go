@@ -0,0 +1,153 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Notes holds the parsed contents of a release notes document.
+type Notes struct {
+	Title    string     // English title, such as "Go 1.5 Release Notes"
+	TitleZH  string     // Chinese title; empty if untranslated
+	Path     string     // URL path of the page, such as "/doc/go1.5"
+	Sections []*Section `json:",omitempty"`
+}
+
+// A Section is a headed group of release notes entries.
+type Section struct {
+	ID      string // anchor name
+	IDZH    string // anchor name of the translated heading, such as "引言"
+	English string // heading, as HTML
+	Chinese string // translated heading, as HTML; empty if untranslated
+	Entries []*Entry
+}
+
+// An Entry is a single item of the release notes: a paragraph of prose or
+// one line of the "package: change" list.
+type Entry struct {
+	Packages []string // import paths of the packages the entry refers to
+	English  string   // original text, as HTML
+	Chinese  string   // translated text, as HTML; empty if untranslated
+	CLs      []string `json:",omitempty"` // code review URLs
+
+	// Docs holds the translated package documentation for each of
+	// Packages, in the same order. It is filled in by linkDocs.
+	Docs []*pkgLink `json:",omitempty"`
+}
+
+// Translated reports whether the entry has a Chinese version.
+func (e *Entry) Translated() bool {
+	return strings.TrimSpace(e.Chinese) != ""
+}
+
+// Entries returns all entries of n in document order.
+func (n *Notes) Entries() []*Entry {
+	var entries []*Entry
+	for _, s := range n.Sections {
+		entries = append(entries, s.Entries...)
+	}
+	return entries
+}
+
+// ByPackage returns the entries of n indexed by the import paths they refer
+// to. Entries that mention several packages appear under each of them.
+func (n *Notes) ByPackage() map[string][]*Entry {
+	m := make(map[string][]*Entry)
+	for _, e := range n.Entries() {
+		for _, p := range e.Packages {
+			m[p] = append(m[p], e)
+		}
+	}
+	return m
+}
+
+// Merge copies the Chinese text of entries and headings in old into the
+// untranslated entries and headings of n that have the same English text.
+func (n *Notes) Merge(old *Notes) {
+	if n.TitleZH == "" {
+		n.TitleZH = old.TitleZH
+	}
+	zh := make(map[string]string)
+	ids := make(map[string]string)
+	for _, s := range old.Sections {
+		if s.Chinese != "" {
+			zh[plainText(s.English)] = s.Chinese
+			ids[plainText(s.English)] = s.IDZH
+		}
+		for _, e := range s.Entries {
+			if e.Translated() {
+				zh[plainText(e.English)] = e.Chinese
+			}
+		}
+	}
+	for _, s := range n.Sections {
+		if s.Chinese == "" {
+			s.Chinese = zh[plainText(s.English)]
+			s.IDZH = ids[plainText(s.English)]
+		}
+		for _, e := range s.Entries {
+			if !e.Translated() {
+				e.Chinese = zh[plainText(e.English)]
+			}
+		}
+	}
+}
+
+var (
+	tagRE   = regexp.MustCompile(`<[^>]*>`)
+	spaceRE = regexp.MustCompile(`\s+`)
+)
+
+// plainText strips the markup from an HTML fragment and collapses white
+// space, so that fragments can be compared by their text alone.
+func plainText(s string) string {
+	s = tagRE.ReplaceAllString(s, " ")
+	return strings.TrimSpace(spaceRE.ReplaceAllString(s, " "))
+}
+
+// anchorID returns an anchor name derived from a heading.
+func anchorID(s string) string {
+	s = strings.ToLower(plainText(s))
+	var b []byte
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9':
+			b = append(b, byte(r))
+		case len(b) > 0 && b[len(b)-1] != '_':
+			b = append(b, '_')
+		}
+	}
+	return strings.TrimSuffix(string(b), "_")
+}
+
+// AnchorZH returns the anchor name of the translated heading: IDZH, or one
+// made of its text, which differs from the anchor of the English heading.
+func (s *Section) AnchorZH() string {
+	if s.IDZH != "" {
+		return s.IDZH
+	}
+	id := strings.Replace(plainText(s.Chinese), " ", "_", -1)
+	if id == s.ID {
+		id = "zh_" + id
+	}
+	return id
+}
+
+// appendUnique appends the elements of add to list that are not already
+// in it.
+func appendUnique(list []string, add ...string) []string {
+Add:
+	for _, a := range add {
+		for _, l := range list {
+			if l == a {
+				continue Add
+			}
+		}
+		list = append(list, a)
+	}
+	return list
+}
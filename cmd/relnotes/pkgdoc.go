@@ -0,0 +1,147 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"unicode"
+)
+
+// A pkgDoc describes the translated documentation of a package, as found in
+// its doc_zh_CN.go file.
+type pkgDoc struct {
+	Path       string          // import path
+	Translated bool            // whether the package comment is translated
+	Symbols    map[string]bool // documented names, such as "Reader.Discard", and whether they are translated
+}
+
+// loadPkgDoc reads the documentation of the package with the given import
+// path from the translations tree at root. It returns nil if the package
+// has no translated documentation.
+func loadPkgDoc(root, path string) (*pkgDoc, error) {
+	filename := filepath.Join(root, "src", filepath.FromSlash(path), "doc_zh_CN.go")
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, nil
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	d := &pkgDoc{
+		Path:       path,
+		Translated: hasChinese(f.Doc),
+		Symbols:    make(map[string]bool),
+	}
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				name = recvName(decl.Recv.List[0].Type) + "." + name
+			}
+			d.Symbols[name] = hasChinese(decl.Doc)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				doc := decl.Doc
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Doc != nil {
+						doc = spec.Doc
+					}
+					d.Symbols[spec.Name.Name] = hasChinese(doc)
+				case *ast.ValueSpec:
+					if spec.Doc != nil {
+						doc = spec.Doc
+					}
+					for _, id := range spec.Names {
+						d.Symbols[id.Name] = hasChinese(doc)
+					}
+				}
+			}
+		}
+	}
+	return d, nil
+}
+
+// recvName returns the base type name of a method receiver.
+func recvName(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.StarExpr:
+		return recvName(x.X)
+	case *ast.Ident:
+		return x.Name
+	}
+	return ""
+}
+
+// hasChinese reports whether the comment group contains Chinese text.
+func hasChinese(g *ast.CommentGroup) bool {
+	if g == nil {
+		return false
+	}
+	for _, r := range g.Text() {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// A pkgLink links a release notes entry to the translated documentation of
+// one of the packages it refers to.
+type pkgLink struct {
+	Path       string // import path
+	URL        string // documentation URL, with the symbol anchor if known
+	Exists     bool   // whether the package has translated documentation
+	Translated bool   // whether the linked documentation is translated
+}
+
+var symbolRE = regexp.MustCompile(`\b[A-Z][A-Za-z0-9_]*(?:\.[A-Z][A-Za-z0-9_]*)?\b`)
+
+// linkDocs fills in the Docs field of every entry of n, using the package
+// documentation found in the translations tree at root.
+func linkDocs(n *Notes, root string) error {
+	cache := make(map[string]*pkgDoc)
+	for _, e := range n.Entries() {
+		e.Docs = nil
+		for _, path := range e.Packages {
+			d, ok := cache[path]
+			if !ok {
+				var err error
+				d, err = loadPkgDoc(root, path)
+				if err != nil {
+					return err
+				}
+				cache[path] = d
+			}
+			e.Docs = append(e.Docs, newPkgLink(path, d, e.English))
+		}
+	}
+	return nil
+}
+
+// newPkgLink links the entry text to the documentation d of package path.
+// If the text mentions a symbol documented in d, the link points to it.
+func newPkgLink(path string, d *pkgDoc, text string) *pkgLink {
+	l := &pkgLink{Path: path, URL: "/pkg/" + path + "/"}
+	if d == nil {
+		return l
+	}
+	l.Exists = true
+	l.Translated = d.Translated
+	for _, sym := range symbolRE.FindAllString(plainText(text), -1) {
+		if tr, ok := d.Symbols[sym]; ok {
+			l.URL += "#" + sym
+			l.Translated = tr
+			break
+		}
+	}
+	return l
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"text/template"
+)
+
+// render writes n as a bilingual page in the format of the other documents
+// in doc/zh_CN: each English block is wrapped in <div class="english"> and
+// followed by its translation. Every entry is followed by a note on its
+// translation status and that of the package documentation it refers to.
+// A translated heading has an anchor of its own; an untranslated one is
+// shown in English, marked like the untranslated entries.
+//
+// The page is valid input to parseHTML, so that translations made in the
+// generated page survive when it is regenerated with the -merge flag.
+func render(w io.Writer, n *Notes) error {
+	return pageTemplate.Execute(w, n)
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"json": func(s string) (string, error) {
+		b, err := json.Marshal(s)
+		return string(b), err
+	},
+}).Parse(`{{with .TitleZH}}<!--{
+	"Title": {{json .}},
+	"Path":  {{json $.Path}},
+	"Template": true
+}-->
+
+{{end}}<!--{
+	"Title": {{json .Title}},
+	"Path":  {{json .Path}},
+	"Template": true
+}-->
+{{range .Sections}}{{if .English}}
+<div class="english">
+<h2{{with .ID}} id="{{.}}"{{end}}>{{.English}}</h2>
+</div>
+
+{{if .Chinese}}<h2 id="{{.AnchorZH}}">{{.Chinese}}</h2>{{else}}<h2 class="relnote-todo">{{.English}}</h2>{{end}}
+{{end}}{{range .Entries}}{{if .English}}
+<div class="english">
+{{.English}}
+</div>
+{{end}}
+{{if .Translated}}{{.Chinese}}{{else}}<p class="relnote-todo">
+（未翻译）
+</p>{{end}}
+{{template "status" .}}
+{{end}}{{end}}
+{{define "status"}}<p class="relnote-status">
+{{if .Translated}}已翻译{{else}}未翻译{{end}}{{range .Docs}} |
+{{if .Exists}}<a href="{{.URL}}"><code>{{.Path}}</code></a> {{if .Translated}}文档已翻译{{else}}文档未翻译{{end}}{{else}}<code>{{.Path}}</code> 无文档{{end}}{{end}}
+</p>{{end}}
+`))
@@ -0,0 +1,127 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// txtSection matches a section heading such as "Tools:".
+	txtSection = regexp.MustCompile(`^([A-Z][^:]*):$`)
+
+	// txtPackage matches a line such as "bytes, strings: add Reader.Size".
+	txtPackage = regexp.MustCompile(`^([a-z0-9_./]+(?:, [a-z0-9_./]+)*): (.*)$`)
+
+	// txtCLs matches the trailing list of code reviews of a line, such as
+	// "(https://golang.org/cl/2470, https://golang.org/cl/2993)" or
+	// "(https://golang.org/cl/7163, 7282, 7283)".
+	txtCLs = regexp.MustCompile(`\s*\((https?://golang\.org/cl/[^)]*)\)\s*$`)
+
+	clNumber = regexp.MustCompile(`\b[0-9]{3,}\b`)
+)
+
+// parseText parses release notes in the plain text format used for notes
+// that have not been written up yet, such as doc/zh_CN/go1.5.txt.
+//
+// Lines ending in a colon start a new section. Every other unindented line
+// is an entry, optionally prefixed by the packages it affects and followed
+// by the code reviews that made the change. Indented lines are preformatted
+// text that belongs to the preceding entry.
+func parseText(r io.Reader) (*Notes, error) {
+	var (
+		n    = new(Notes)
+		sec  *Section
+		last *Entry
+		pre  []string // pending indented lines of last
+	)
+	flushPre := func() {
+		if last != nil && len(pre) > 0 {
+			text := strings.TrimRight(strings.Join(pre, "\n"), "\n\t ")
+			last.English += "\n<pre>\n" + html.EscapeString(text) + "\n</pre>"
+		}
+		pre = nil
+	}
+	s := bufio.NewScanner(r)
+	for lineno := 1; s.Scan(); lineno++ {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		switch {
+		case line == "":
+			if len(pre) > 0 {
+				pre = append(pre, "")
+			}
+			continue
+		case line[0] == '\t' || line[0] == ' ':
+			if last == nil {
+				return nil, fmt.Errorf("line %d: indented text outside of an entry", lineno)
+			}
+			pre = append(pre, strings.TrimPrefix(line, "\t"))
+			continue
+		}
+		flushPre()
+		if m := txtSection.FindStringSubmatch(line); m != nil {
+			sec = &Section{ID: anchorID(m[1]), English: html.EscapeString(m[1])}
+			n.Sections = append(n.Sections, sec)
+			last = nil
+			continue
+		}
+		if sec == nil {
+			sec = new(Section)
+			n.Sections = append(n.Sections, sec)
+		}
+		last = parseTextEntry(line)
+		sec.Entries = append(sec.Entries, last)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	flushPre()
+	return n, nil
+}
+
+// parseTextEntry parses a single unindented line of the text format.
+func parseTextEntry(line string) *Entry {
+	e := new(Entry)
+	if m := txtCLs.FindStringSubmatchIndex(line); m != nil {
+		for _, num := range clNumber.FindAllString(line[m[2]:m[3]], -1) {
+			e.CLs = append(e.CLs, "https://golang.org/cl/"+num)
+		}
+		line = line[:m[0]]
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<p>\n")
+	if m := txtPackage.FindStringSubmatch(line); m != nil {
+		for i, p := range strings.Split(m[1], ", ") {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			e.Packages = appendUnique(e.Packages, p)
+			fmt.Fprintf(&b, "<code>%s</code>", html.EscapeString(p))
+		}
+		b.WriteString(": ")
+		line = m[2]
+	}
+	b.WriteString(html.EscapeString(line))
+	if len(e.CLs) > 0 {
+		b.WriteString(" (")
+		for i, cl := range e.CLs {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, `<a href="%s">CL %s</a>`, cl, cl[strings.LastIndex(cl, "/")+1:])
+		}
+		b.WriteString(")")
+	}
+	b.WriteString("\n</p>")
+	e.English = b.String()
+	return e
+}
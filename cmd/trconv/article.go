@@ -0,0 +1,195 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// In the blog articles, each original paragraph is followed by its
+// translation, and both are marked with comment lines: #en starts the
+// original, #zh the translation, and #end ends the block. Text outside of
+// these blocks is shared by both languages.
+const (
+	markEN  = "#en"
+	markZH  = "#zh"
+	markEnd = "#end"
+)
+
+// Older articles enclose the original in a pair of .html commands including
+// _tr/div_begin_en.html and _tr/div_end.html, and the translation in
+// _tr/div_begin_zh_CN.html and _tr/div_end.html. They are still read.
+const (
+	beginEN  = ".html _tr/div_begin_en.html"
+	beginZH  = ".html _tr/div_begin_zh_CN.html"
+	endBlock = ".html _tr/div_end.html"
+
+	// leadPrefix starts the comment lines that keep the metadata of an
+	// HTML page in the article generated from it.
+	leadPrefix = "#html:"
+)
+
+// parseArticle parses a present article of blog/zh_CN/content.
+//
+// The header of the article extends up to the first section heading. An
+// empty heading, "* ", which articles use to end the author block, belongs
+// to the header.
+func parseArticle(src string) (*document, error) {
+	d := new(document)
+	lines := strings.Split(strings.Replace(src, "\r\n", "\n", -1), "\n")
+
+	var (
+		header []string
+		lead   []string
+		n      int
+	)
+	for ; n < len(lines); n++ {
+		line := lines[n]
+		if strings.HasPrefix(line, "*") || isMarker(line) {
+			if strings.TrimSpace(line) == "*" {
+				header = append(header, line)
+				n++
+			}
+			break
+		}
+		if strings.HasPrefix(line, leadPrefix) {
+			line = strings.TrimPrefix(line, leadPrefix)
+			lead = append(lead, strings.TrimPrefix(line, " "))
+		}
+		header = append(header, line)
+	}
+	if len(lead) > 0 {
+		d.lead = strings.TrimRight(strings.Join(lead, "\n"), " \t\n")
+	} else {
+		d.header = strings.TrimRight(strings.Join(header, "\n"), "\n")
+	}
+
+	var (
+		buf   bytes.Buffer
+		k     = shared
+		start int // line number of the current block
+	)
+	for ; n < len(lines); n++ {
+		line := lines[n]
+		switch markerText(line) {
+		case markZH:
+			// #zh ends the original it follows.
+			if k == english {
+				d.add(k, buf.String())
+				buf.Reset()
+				k = shared
+			}
+			fallthrough
+		case beginEN, beginZH, markEN:
+			if k != shared {
+				return nil, fmt.Errorf("line %d: %s block inside %s block started at line %d", n+1, kindOf(line), k, start)
+			}
+			d.add(shared, buf.String())
+			buf.Reset()
+			k, start = kindOf(line), n+1
+			continue
+		case endBlock, markEnd:
+			if k == shared {
+				return nil, fmt.Errorf("line %d: unexpected end of block", n+1)
+			}
+			d.add(k, buf.String())
+			buf.Reset()
+			k = shared
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if k != shared {
+		return nil, fmt.Errorf("line %d: unterminated %s block", start, k)
+	}
+	d.add(shared, buf.String())
+	return d, nil
+}
+
+// isMarker reports whether the line is one of the block markers.
+func isMarker(line string) bool {
+	switch markerText(line) {
+	case markEN, markZH, markEnd, beginEN, beginZH, endBlock:
+		return true
+	}
+	return false
+}
+
+// markerText returns line without its trailing space. Markers start at
+// column 0, as in the blog, so that an indented line of code reading #en
+// stays code.
+func markerText(line string) string {
+	return strings.TrimRight(line, " \t\r")
+}
+
+func kindOf(marker string) kind {
+	switch markerText(marker) {
+	case markZH, beginZH:
+		return chinese
+	}
+	return english
+}
+
+// writeArticle writes d as a present article in the format of
+// blog/zh_CN/content.
+func writeArticle(d *document) []byte {
+	var buf bytes.Buffer
+	if d.header != "" {
+		buf.WriteString(d.header)
+		buf.WriteString("\n\n")
+	} else {
+		// Follow the blog's convention of commenting out the English
+		// title above the Chinese one.
+		title, titleEN := pageTitles(d.lead)
+		if titleEN != "" {
+			fmt.Fprintf(&buf, "#%s\n", titleEN)
+		}
+		fmt.Fprintf(&buf, "%s\n\n", title)
+		for _, line := range strings.Split(d.lead, "\n") {
+			fmt.Fprintf(&buf, "%s %s\n", leadPrefix, line)
+		}
+		buf.WriteString("\n* \n\n")
+	}
+	for i, s := range d.segments {
+		switch s.kind {
+		case english:
+			fmt.Fprintf(&buf, "%s\n%s\n", markEN, s.text)
+			if i+1 < len(d.segments) && d.segments[i+1].kind == chinese {
+				// The translation continues the block.
+				continue
+			}
+			fmt.Fprintf(&buf, "%s\n\n", markEnd)
+		case chinese:
+			fmt.Fprintf(&buf, "%s\n%s\n%s\n\n", markZH, s.text, markEnd)
+		default:
+			fmt.Fprintf(&buf, "%s\n\n", s.text)
+		}
+	}
+	return trimBlankLines(buf.Bytes())
+}
+
+// articleTitles returns the titles from the header of a present article.
+// The title is the first line that is not a comment; a translated article
+// keeps its original title in a comment just above it.
+func articleTitles(header string) (title, titleEN string) {
+	var prev string
+	for _, line := range strings.Split(header, "\n") {
+		if strings.HasPrefix(line, "#") {
+			prev = line
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(prev, "#") && !strings.HasPrefix(prev, leadPrefix) {
+			titleEN = strings.TrimSpace(strings.TrimPrefix(prev, "#"))
+		}
+		return strings.TrimSpace(line), titleEN
+	}
+	return "", ""
+}
@@ -0,0 +1,81 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A kind tells the role of a segment in a bilingual document.
+type kind int
+
+const (
+	shared  kind = iota // not translated: code, images, untranslatable text
+	english             // original text
+	chinese             // translated text
+)
+
+func (k kind) String() string {
+	switch k {
+	case english:
+		return "english"
+	case chinese:
+		return "chinese"
+	}
+	return "shared"
+}
+
+// A segment is a block of a bilingual document. Its text is kept verbatim
+// in the markup of the source document, without leading or trailing
+// newlines.
+type segment struct {
+	kind kind
+	text string
+}
+
+// A document is a bilingual document independent of its representation
+// as an HTML page or as a present article.
+//
+// Only one of lead and header is set, depending on where the document
+// came from; the other is generated when the document is written in the
+// other format, and dropped again when it is converted back.
+type document struct {
+	lead     string // metadata of an HTML page, verbatim
+	header   string // header of a present article, verbatim
+	segments []segment
+}
+
+// add appends a segment of the given kind to d. Blank text is dropped.
+func (d *document) add(k kind, text string) {
+	text = strings.Trim(text, "\n")
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	d.segments = append(d.segments, segment{k, text})
+}
+
+// equal reports whether d and e have the same content. If not, the error
+// describes the first difference.
+func (d *document) equal(e *document) error {
+	switch {
+	case d.lead != e.lead:
+		return fmt.Errorf("page metadata differs")
+	case d.header != e.header:
+		return fmt.Errorf("article header differs")
+	}
+	for i := range d.segments {
+		if i >= len(e.segments) {
+			return fmt.Errorf("segment %d (%v) missing", i+1, d.segments[i].kind)
+		}
+		if d.segments[i] != e.segments[i] {
+			return fmt.Errorf("segment %d (%v) differs", i+1, d.segments[i].kind)
+		}
+	}
+	if len(e.segments) > len(d.segments) {
+		return fmt.Errorf("extra segment %d (%v)", len(d.segments)+1, e.segments[len(d.segments)].kind)
+	}
+	return nil
+}
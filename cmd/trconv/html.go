@@ -0,0 +1,192 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// In the HTML pages under doc/zh_CN, the original text is wrapped in
+// <div class="english"> and followed by its translation. Text that is the
+// same in both languages is wrapped in <div class="shared">.
+const (
+	englishDiv = `<div class="english">`
+	sharedDiv  = `<div class="shared">`
+	endDiv     = `</div>`
+
+	// articleComment starts the comment that keeps the header of a
+	// present article in the HTML page generated from it.
+	articleComment = "<!--article:\n"
+)
+
+var metaRE = regexp.MustCompile(`(?s)<!--(\{.*?\})-->`)
+
+// parseHTML parses an HTML page of doc/zh_CN.
+//
+// Pages without any <div class="english"> or <div class="shared"> are
+// untranslated; their text is split into English segments at blank lines
+// between elements.
+func parseHTML(src string) (*document, error) {
+	d := new(document)
+
+	// The page starts with one or more metadata comments.
+	body := src
+	for {
+		s := strings.TrimLeft(body, " \t\n")
+		if !strings.HasPrefix(s, "<!--") {
+			break
+		}
+		end := strings.Index(s, "-->")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated comment")
+		}
+		if strings.HasPrefix(s, articleComment) {
+			d.header = strings.TrimRight(s[len(articleComment):end], "\n")
+		}
+		body = s[end+len("-->"):]
+	}
+	if d.header == "" {
+		d.lead = strings.TrimRight(src[:len(src)-len(body)], " \t\n")
+	}
+
+	if !strings.Contains(body, englishDiv) && !strings.Contains(body, sharedDiv) {
+		for _, p := range splitParagraphs(body) {
+			d.add(english, p)
+		}
+		return d, nil
+	}
+	for body != "" {
+		i, k, tag := strings.Index(body, englishDiv), english, englishDiv
+		if j := strings.Index(body, sharedDiv); j >= 0 && (i < 0 || j < i) {
+			i, k, tag = j, shared, sharedDiv
+		}
+		if i < 0 {
+			d.add(chinese, body)
+			break
+		}
+		d.add(chinese, body[:i])
+		body = body[i+len(tag):]
+		end := matchingEndDiv(body)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated %s near %q", k, firstLine(body))
+		}
+		d.add(k, body[:end])
+		body = body[end+len(endDiv):]
+	}
+	return d, nil
+}
+
+// matchingEndDiv returns the index in s of the </div> that closes a <div>
+// opened just before s, or -1.
+func matchingEndDiv(s string) int {
+	depth := 1
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "<div"):
+			depth++
+		case strings.HasPrefix(s[i:], endDiv):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// containerTags lists the elements whose content may contain blank lines.
+var containerTags = []string{"blockquote", "div", "ol", "pre", "table", "ul"}
+
+// splitParagraphs splits HTML text at blank lines outside container elements
+// such as <pre> and <div>.
+func splitParagraphs(s string) []string {
+	var (
+		paras []string
+		buf   bytes.Buffer
+		depth int
+	)
+	for _, line := range strings.Split(s, "\n") {
+		for _, tag := range containerTags {
+			depth += strings.Count(line, "<"+tag) - strings.Count(line, "</"+tag+">")
+		}
+		if strings.TrimSpace(line) == "" && depth <= 0 {
+			paras = append(paras, buf.String())
+			buf.Reset()
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return append(paras, buf.String())
+}
+
+// writeHTML writes d as an HTML page in the format of doc/zh_CN.
+func writeHTML(d *document) ([]byte, error) {
+	var buf bytes.Buffer
+	if d.lead != "" {
+		buf.WriteString(d.lead)
+		buf.WriteString("\n\n")
+	} else {
+		title, titleEN := articleTitles(d.header)
+		for _, t := range []string{title, titleEN} {
+			if t == "" {
+				continue
+			}
+			b, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&buf, "<!--{\n\t\"Title\": %s\n}-->\n\n", b)
+		}
+		fmt.Fprintf(&buf, "%s%s\n-->\n\n", articleComment, d.header)
+	}
+	for _, s := range d.segments {
+		switch s.kind {
+		case english:
+			fmt.Fprintf(&buf, "%s\n%s\n%s\n\n", englishDiv, s.text, endDiv)
+		case shared:
+			fmt.Fprintf(&buf, "%s\n%s\n%s\n\n", sharedDiv, s.text, endDiv)
+		default:
+			fmt.Fprintf(&buf, "%s\n\n", s.text)
+		}
+	}
+	return trimBlankLines(buf.Bytes()), nil
+}
+
+// pageTitles returns the titles from the metadata of an HTML page. A
+// translated page has its own metadata followed by that of the original.
+func pageTitles(lead string) (title, titleEN string) {
+	var titles []string
+	for _, m := range metaRE.FindAllStringSubmatch(lead, 2) {
+		var meta struct{ Title string }
+		if json.Unmarshal([]byte(m[1]), &meta) == nil {
+			titles = append(titles, meta.Title)
+		}
+	}
+	switch len(titles) {
+	case 0:
+		return "", ""
+	case 1:
+		return titles[0], ""
+	}
+	return titles[0], titles[1]
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// trimBlankLines replaces the trailing blank lines of b by a single newline.
+func trimBlankLines(b []byte) []byte {
+	return append(bytes.TrimRight(b, "\n"), '\n')
+}
@@ -0,0 +1,183 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Trconv converts bilingual documents between the HTML format of doc/zh_CN
+// and the present article format of blog/zh_CN/content, so that both kinds
+// of content can be handled by the same translation tools.
+//
+// Usage:
+//
+//	trconv [-o output] file
+//	trconv -w file.article...
+//
+// An HTML file is converted to an article and an .article file to HTML. In
+// HTML, original text is wrapped in <div class="english"> and followed by
+// its translation:
+//
+//	<div class="english">
+//	<p>Go is expressive, concise, clean, and efficient.</p>
+//	</div>
+//
+//	<p>Go 富有表现力、简洁、清晰且高效。</p>
+//
+// In an article, the original and the translation are marked with comment
+// lines:
+//
+//	#en
+//	Go is expressive, concise, clean, and efficient.
+//	#zh
+//	Go 富有表现力、简洁、清晰且高效。
+//	#end
+//
+// Text outside of the markers in an article is shared by both languages and
+// becomes <div class="shared"> in HTML.
+//
+// Articles may also use the older syntax, in which the original and the
+// translation are enclosed in _tr include markers:
+//
+//	.html _tr/div_begin_en.html
+//
+//	Go is expressive, concise, clean, and efficient.
+//
+//	.html _tr/div_end.html
+//
+//	.html _tr/div_begin_zh_CN.html
+//
+//	Go 富有表现力、简洁、清晰且高效。
+//
+//	.html _tr/div_end.html
+//
+// The -w flag rewrites articles in place in the comment syntax.
+//
+// Trconv maps the bilingual structure only: the text of each block is copied
+// verbatim in the markup it was written in. The page metadata of an HTML file
+// is kept in "#html:" comment lines of the article, and the header of an
+// article in an "<!--article:" comment of the HTML page, so that converting
+// the result back reproduces the original, up to blank lines between blocks
+// and line endings. Trconv checks this before writing its output.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+var (
+	output  = flag.String("o", "", "output `file` (default standard output)")
+	migrate = flag.Bool("w", false, "rewrite articles in place in the comment syntax")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: trconv [-o output] file.html|file.article\n")
+	fmt.Fprintf(os.Stderr, "       trconv -w file.article...\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trconv: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *migrate {
+		if flag.NArg() == 0 || *output != "" {
+			usage()
+		}
+		failed := false
+		for _, name := range flag.Args() {
+			if err := rewrite(name); err != nil {
+				log.Printf("%s: %v", name, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() != 1 {
+		usage()
+	}
+	name := flag.Arg(0)
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out, err := convert(filepath.Ext(name), string(b))
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(out)
+	} else {
+		err = ioutil.WriteFile(*output, out, 0666)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// convert converts src, an HTML page or a present article according to the
+// file name extension ext, to the other format. It fails if converting the
+// result back would not give the same document.
+func convert(ext, src string) ([]byte, error) {
+	var (
+		parse, parseBack func(string) (*document, error)
+		write            func(*document) ([]byte, error)
+	)
+	switch ext {
+	case ".html":
+		parse, parseBack = parseHTML, parseArticle
+		write = func(d *document) ([]byte, error) { return writeArticle(d), nil }
+	case ".article":
+		parse, parseBack = parseArticle, parseHTML
+		write = writeHTML
+	default:
+		return nil, fmt.Errorf("unknown file type %q", ext)
+	}
+	d, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	out, err := write(d)
+	if err != nil {
+		return nil, err
+	}
+	back, err := parseBack(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("converted document does not parse: %v", err)
+	}
+	if err := d.equal(back); err != nil {
+		return nil, fmt.Errorf("conversion loses content: %v", err)
+	}
+	return out, nil
+}
+
+// rewrite rewrites the article in the named file in the comment syntax.
+func rewrite(name string) error {
+	if filepath.Ext(name) != ".article" {
+		return fmt.Errorf("not an article")
+	}
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	d, err := parseArticle(string(b))
+	if err != nil {
+		return err
+	}
+	out := writeArticle(d)
+	back, err := parseArticle(string(out))
+	if err != nil {
+		return fmt.Errorf("rewritten article does not parse: %v", err)
+	}
+	if err := d.equal(back); err != nil {
+		return fmt.Errorf("rewriting loses content: %v", err)
+	}
+	return ioutil.WriteFile(name, out, 0666)
+}
@@ -0,0 +1,3 @@
+module doc/articles/wiki
+
+go 1.22
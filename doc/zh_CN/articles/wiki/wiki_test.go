@@ -0,0 +1,259 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The programs of the tutorial are all in package main, and are excluded
+// from the package by a build tag. They are built one at a time by naming
+// their file on the go command line.
+
+// programs returns the names of the tutorial programs.
+func programs(t *testing.T) []string {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var progs []string
+	for _, f := range files {
+		if !strings.HasSuffix(f, "_test.go") {
+			progs = append(progs, f)
+		}
+	}
+	return progs
+}
+
+func TestSnippetsCompile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	dir, err := ioutil.TempDir("", "wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range programs(t) {
+		bin := filepath.Join(dir, strings.TrimSuffix(f, ".go"))
+		out, err := exec.Command("go", "build", "-o", bin, f).CombinedOutput()
+		if err != nil {
+			t.Errorf("go build %s: %v\n%s", f, err, out)
+		}
+	}
+}
+
+func TestWikiServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	dir, err := ioutil.TempDir("", "wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The server reads its templates from, and writes its pages to, the
+	// current directory, so run it in a scratch directory.
+	bin := filepath.Join(dir, "final.exe")
+	if out, err := exec.Command("go", "build", "-o", bin, "final.go").CombinedOutput(); err != nil {
+		t.Fatalf("go build final.go: %v\n%s", err, out)
+	}
+	for _, name := range []string{"edit.html", "view.html"} {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// With -addr, the server listens on a free loopback port and writes
+	// its address to final-port.txt.
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, "-addr")
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		if stderr.Len() > 0 {
+			t.Logf("server output:\n%s", stderr.Bytes())
+		}
+	}()
+	addr, err := waitForAddr(filepath.Join(dir, "final-port.txt"), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "http://" + addr
+
+	check := func(what string, got []byte, golden string) {
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got\n%s\nwant (%s)\n%s", what, got, golden, want)
+		}
+	}
+
+	// Editing a page that does not exist yet shows an empty form.
+	resp, err := http.Get(base + "/edit/Test")
+	check("GET /edit/Test", body(t, resp, err), "test_edit.good")
+
+	// Saving redirects to the view of the page.
+	resp, err = http.PostForm(base+"/save/Test", url.Values{"body": {"some content"}})
+	check("POST /save/Test", body(t, resp, err), "test_view.good")
+
+	saved, err := ioutil.ReadFile(filepath.Join(dir, "Test.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Test.txt", saved, "test_Test.txt.good")
+
+	resp, err = http.Get(base + "/view/Test")
+	check("GET /view/Test", body(t, resp, err), "test_view.good")
+}
+
+// waitForAddr waits for the server to write its address to file.
+func waitForAddr(file string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		b, err := ioutil.ReadFile(file)
+		if err == nil && len(b) > 0 {
+			return string(b), nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("server address not available within %v", timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// body returns the body of a successful response, or fails the test.
+func body(t *testing.T, resp *http.Response, err error) []byte {
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s %s: %s\n%s", resp.Request.Method, resp.Request.URL, resp.Status, b)
+	}
+	return b
+}
+
+// codeRE matches the invocations of the godoc template function code,
+// for example
+//
+//	{{code "doc/articles/wiki/part1.go" `/^func loadPage/` `/^}/`}}
+var codeRE = regexp.MustCompile("{{code \"([^\"]*)\"((?:\\s+(?:`[^`]*`|\"[^\"]*\"|[0-9]+))*)\\s*}}")
+
+var codeArgRE = regexp.MustCompile("`[^`]*`|\"[^\"]*\"|[0-9]+")
+
+// The article refers to the programs by their path in the Go distribution.
+const codePrefix = "doc/articles/wiki/"
+
+func TestCodeReferences(t *testing.T) {
+	b, err := ioutil.ReadFile("index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := codeRE.FindAllSubmatch(b, -1)
+	if len(refs) == 0 {
+		t.Fatal("no code references in index.html")
+	}
+	for _, m := range refs {
+		ref := string(m[0])
+		file := string(m[1])
+		if !strings.HasPrefix(file, codePrefix) {
+			t.Errorf("%s: file not in %s", ref, codePrefix)
+			continue
+		}
+		src, err := ioutil.ReadFile(strings.TrimPrefix(file, codePrefix))
+		if err != nil {
+			t.Errorf("%s: %v", ref, err)
+			continue
+		}
+		var args []string
+		for _, a := range codeArgRE.FindAllString(string(m[2]), -1) {
+			if a[0] == '`' || a[0] == '"' {
+				a = a[1 : len(a)-1]
+			}
+			args = append(args, a)
+		}
+		if err := checkRegion(string(src), args); err != nil {
+			t.Errorf("%s: %v", ref, err)
+		}
+	}
+}
+
+// checkRegion checks that the arguments of a code invocation select a
+// region of src, as godoc does: each argument is a line number, a
+// /regexp/ matching a line, or $ for the last line, and the second one
+// is looked for after the line selected by the first.
+func checkRegion(src string, args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("too many arguments")
+	}
+	lines := strings.SplitAfter(src, "\n")
+	start := 0
+	for i, arg := range args {
+		n, err := matchLine(lines, start, arg)
+		if err != nil {
+			return err
+		}
+		if i > 0 && n < start {
+			return fmt.Errorf("lines out of order: %d %d", start, n)
+		}
+		start = n
+	}
+	return nil
+}
+
+// matchLine returns the 1-based number of the line selected by arg,
+// looking for a pattern from line start+1 on.
+func matchLine(lines []string, start int, arg string) (int, error) {
+	switch {
+	case arg == "$":
+		return len(lines), nil
+	case len(arg) > 2 && arg[0] == '/' && arg[len(arg)-1] == '/':
+		re, err := regexp.Compile(arg[1 : len(arg)-1])
+		if err != nil {
+			return 0, err
+		}
+		for i := start; i < len(lines); i++ {
+			if re.MatchString(lines[i]) {
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("no match for %s", arg)
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n <= 0 || n > len(lines) {
+		return 0, fmt.Errorf("bad line %q", arg)
+	}
+	return n, nil
+}
@@ -1,8 +1,12 @@
-// skip
+// compile
 
 // Copyright 2012 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
+
+// srandom has a different signature on freebsd and netbsd.
+// +build cgo,!freebsd,!netbsd
+
 package rand2
 
 /*
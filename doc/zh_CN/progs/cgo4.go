@@ -1,8 +1,12 @@
-// skip
+// compile
 
 // Copyright 2012 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
+
+// cgo cannot handle stdout correctly on netbsd and openbsd.
+// +build cgo,!netbsd,!openbsd
+
 package print
 
 // #include <stdio.h>
@@ -1,5 +1,4 @@
-// compile
-// this file will output a list of filenames in cwd, not suitable for cmpout
+// cmpout
 
 // Copyright 2011 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
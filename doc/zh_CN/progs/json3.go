@@ -1,4 +1,4 @@
-// compile
+// cmpsorted
 
 // Copyright 2012 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
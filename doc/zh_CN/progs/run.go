@@ -0,0 +1,370 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+// Run builds and runs the programs of this directory, which are included
+// in the documents of doc/zh_CN.
+//
+// Usage:
+//
+//	go run run.go [-update] [-v] [program.go ...]
+//
+// The first line of each program tells what to do with it:
+//
+//	// compile    build the program
+//	// run        build and run the program; it must succeed without output
+//	// cmpout     build and run the program, and compare its output with
+//	//            that in the .out file of the same name
+//	// cmpsorted  like cmpout, but compare the lines of the output in
+//	//            sorted order, for programs that print them in the random
+//	//            order of a map
+//	// skip       ignore the program
+//
+// Programs excluded by their build constraints, for example because they
+// need cgo, are skipped. The programs are built and run in parallel, and
+// each of them is killed if it runs longer than -timeout. Each program runs
+// in a scratch directory holding only a copy of its source, so that its
+// output does not depend on the other files of this directory.
+//
+// With -update, the .out files of the cmpout and cmpsorted programs are
+// rewritten from their output instead.
+//
+// When a program fails, run lists the documents that include it, so that
+// they can be checked too; with -v, it does so for every program. A program
+// that is included by a document but does not exist is a failure as well.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	update  = flag.Bool("update", false, "rewrite the .out files of the cmpout and cmpsorted programs")
+	verbose = flag.Bool("v", false, "print the result of every program")
+	timeout = flag.Duration("timeout", 30*time.Second, "kill a program that runs longer than `d`")
+	jobs    = flag.Int("p", runtime.NumCPU(), "build and run `n` programs in parallel")
+	docDir  = flag.String("docs", "..", "look for the documents including the programs in `dir`")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: go run run.go [flags] [program.go ...]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// A prog is a program of this directory.
+type prog struct {
+	file   string   // file name
+	action string   // compile, run, cmpout, cmpsorted or skip
+	docs   []string // documents including the program
+
+	// Result.
+	skipped bool
+	err     error
+	output  []byte
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("run: ")
+	flag.Usage = usage
+	flag.Parse()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		var err error
+		files, err = filepath.Glob("*.go")
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	docs, err := includes(*docDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tmpDir, err := ioutil.TempDir("", "progs")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var progs []*prog
+	for _, file := range files {
+		if file == "run.go" {
+			continue
+		}
+		action, err := readAction(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		progs = append(progs, &prog{file: file, action: action, docs: docs[file]})
+	}
+
+	var (
+		wg   sync.WaitGroup
+		sema = make(chan bool, *jobs)
+	)
+	for _, p := range progs {
+		wg.Add(1)
+		go func(p *prog) {
+			defer wg.Done()
+			sema <- true
+			p.do(tmpDir)
+			<-sema
+		}(p)
+	}
+	wg.Wait()
+
+	failed := false
+	for _, p := range progs {
+		switch {
+		case p.err != nil:
+			failed = true
+			fmt.Printf("--- FAIL: %s: %v\n", p.file, p.err)
+			if len(p.output) > 0 {
+				fmt.Printf("%s", indent(p.output))
+			}
+			if len(p.docs) > 0 {
+				fmt.Printf("\tincluded in %s\n", strings.Join(p.docs, ", "))
+			}
+		case *verbose && p.skipped:
+			fmt.Printf("--- SKIP: %s\n", p.file)
+		case *verbose:
+			fmt.Printf("--- PASS: %s (%s)\n", p.file, p.action)
+			if len(p.docs) > 0 {
+				fmt.Printf("\tincluded in %s\n", strings.Join(p.docs, ", "))
+			}
+		}
+	}
+	if flag.NArg() == 0 {
+		// Every included program must exist.
+		var missing []string
+		for file := range docs {
+			if _, err := os.Stat(file); err != nil {
+				missing = append(missing, file)
+			}
+		}
+		sort.Strings(missing)
+		for _, file := range missing {
+			failed = true
+			fmt.Printf("--- FAIL: %s: missing\n\tincluded in %s\n", file, strings.Join(docs[file], ", "))
+		}
+	}
+	if failed {
+		fmt.Println("FAIL")
+		os.Exit(1)
+	}
+	fmt.Println("PASS")
+}
+
+var actionRE = regexp.MustCompile(`^// (compile|run|cmpout|cmpsorted|skip)\b`)
+
+// readAction returns the action named on the first line of file.
+func readAction(file string) (string, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	m := actionRE.FindSubmatch(b)
+	if m == nil {
+		return "", fmt.Errorf("%s: first line does not say compile, run, cmpout, cmpsorted or skip", file)
+	}
+	return string(m[1]), nil
+}
+
+// do builds and runs p as told by its action, and records the result.
+func (p *prog) do(tmpDir string) {
+	if p.action == "skip" {
+		p.skipped = true
+		return
+	}
+	ok, err := build.Default.MatchFile(".", p.file)
+	if err != nil {
+		p.err = err
+		return
+	}
+	if !ok {
+		p.skipped = true
+		return
+	}
+
+	bin := filepath.Join(tmpDir, strings.TrimSuffix(p.file, ".go")+".exe")
+	if out, err := exec.Command("go", "build", "-o", bin, p.file).CombinedOutput(); err != nil {
+		p.err, p.output = fmt.Errorf("build failed: %v", err), out
+		return
+	}
+	if p.action == "compile" {
+		return
+	}
+
+	dir := filepath.Join(tmpDir, strings.TrimSuffix(p.file, ".go"))
+	if err := copyToDir(p.file, dir); err != nil {
+		p.err = err
+		return
+	}
+	out, err := runTimeout(bin, dir, *timeout)
+	if err != nil {
+		p.err, p.output = err, out
+		return
+	}
+	switch p.action {
+	case "run":
+		if len(out) > 0 {
+			p.err, p.output = fmt.Errorf("unexpected output"), out
+		}
+	case "cmpout", "cmpsorted":
+		if p.action == "cmpsorted" {
+			out = sortLines(out)
+		}
+		golden := strings.TrimSuffix(p.file, ".go") + ".out"
+		if *update {
+			p.err = ioutil.WriteFile(golden, out, 0666)
+			return
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			p.err = err
+			return
+		}
+		if p.action == "cmpsorted" {
+			want = sortLines(want)
+		}
+		if !bytes.Equal(out, want) {
+			p.err, p.output = fmt.Errorf("output differs from %s", golden), diff(want, out)
+		}
+	}
+}
+
+// copyToDir creates dir and copies file into it.
+func copyToDir(file, dir string) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0777); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, file), b, 0666)
+}
+
+// sortLines returns the lines of b in sorted order.
+func sortLines(b []byte) []byte {
+	lines := strings.SplitAfter(string(b), "\n")
+	if n := len(lines) - 1; lines[n] == "" {
+		lines = lines[:n]
+	} else {
+		lines[n] += "\n"
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, ""))
+}
+
+// runTimeout runs bin in dir and returns its combined output. The program
+// is killed after the timeout.
+func runTimeout(bin, dir string, timeout time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+	cmd := exec.Command(bin)
+	cmd.Dir = dir
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	t := time.AfterFunc(timeout, func() { cmd.Process.Kill() })
+	err := cmd.Wait()
+	if !t.Stop() {
+		return buf.Bytes(), fmt.Errorf("timed out after %v", timeout)
+	}
+	return buf.Bytes(), err
+}
+
+// diff returns the lines that differ between want and got, in the manner
+// of diff -u without context.
+func diff(want, got []byte) []byte {
+	w := strings.SplitAfter(string(want), "\n")
+	g := strings.SplitAfter(string(got), "\n")
+	var buf bytes.Buffer
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl == gl {
+			continue
+		}
+		if wl != "" {
+			fmt.Fprintf(&buf, "-%s", noEOL(wl))
+		}
+		if gl != "" {
+			fmt.Fprintf(&buf, "+%s", noEOL(gl))
+		}
+	}
+	return buf.Bytes()
+}
+
+func noEOL(line string) string {
+	if !strings.HasSuffix(line, "\n") {
+		return line + "\n\\ No newline at end of file\n"
+	}
+	return line
+}
+
+var lineStartRE = regexp.MustCompile(`(?m)^`)
+
+func indent(b []byte) []byte {
+	b = bytes.TrimRight(b, "\n")
+	return append(lineStartRE.ReplaceAll(b, []byte("\t")), '\n')
+}
+
+// includeRE matches the godoc template calls including a program of
+// this directory, such as {{code "/doc/progs/go1.go" `/STOP/`}}.
+var includeRE = regexp.MustCompile(`{{(?:code|play) "/?doc/progs/([^"/]+\.go)"`)
+
+// includes returns the documents under dir that include each program,
+// keyed by file name.
+func includes(dir string) (map[string][]string, error) {
+	docs := make(map[string][]string)
+	err := filepath.Walk(dir, func(name string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || filepath.Ext(name) != ".html" {
+			return err
+		}
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		for _, m := range includeRE.FindAllSubmatch(b, -1) {
+			file := string(m[1])
+			if n := len(docs[file]); n == 0 || docs[file][n-1] != rel {
+				docs[file] = append(docs[file], rel)
+			}
+		}
+		return nil
+	})
+	for _, d := range docs {
+		sort.Strings(d)
+	}
+	return docs, err
+}
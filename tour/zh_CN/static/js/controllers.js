@@ -72,7 +72,7 @@ controller('EditorCtrl', ['$scope', '$routeParams', '$location', 'toc', 'i18n',
         }
 
         $scope.run = function() {
-            log('info', i18n.l('waiting'));
+            log('info', i18n.l('editor.waiting'));
             var f = file();
             run(f.Content, $('.output.active > pre')[0], {
                 path: f.Name
@@ -80,7 +80,7 @@ controller('EditorCtrl', ['$scope', '$routeParams', '$location', 'toc', 'i18n',
         };
 
         $scope.format = function() {
-            log('info', i18n.l('waiting'));
+            log('info', i18n.l('editor.waiting'));
             fmt(file().Content).then(
                 function(data) {
                     if (data.data.Error !== '') {
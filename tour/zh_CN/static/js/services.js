@@ -18,18 +18,34 @@ factory('analytics', ['$window',
     }
 ]).
 
-// Internationalization
-factory('i18n', ['translation',
-    function(translation) {
+// Internationalization: the messages of the locale of the tour, which
+// cmd/msgextract writes to static/messages/<locale>.json from the catalogs
+// of template/messages. They are empty until the catalog is loaded.
+factory('i18n', ['$http', 'locale',
+    function($http, locale) {
+        var messages = null;
+        $http.get('/static/messages/' + locale + '.json').success(function(data) {
+            messages = data;
+        });
         return {
             l: function(key) {
-                if (translation[key]) return translation[key];
+                if (messages === null) return '';
+                if (messages[key]) return messages[key];
                 return '(no translation for ' + key + ')';
             }
         };
     }
 ]).
 
+// msg looks up a message of the tour by key, as in {{'editor.run' | msg}}.
+filter('msg', ['i18n',
+    function(i18n) {
+        return function(key) {
+            return i18n.l(key);
+        };
+    }
+]).
+
 // Running code
 factory('run', ['$window', 'editor',
     function(win, editor) {
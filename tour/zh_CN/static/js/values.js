@@ -29,24 +29,9 @@ value('tableOfContents', [{
     'lessons': ['concurrency']
 }]).
 
-// translation
-value('translation', {
-    'off': 'off',
-    'on': 'on',
-    'syntax': 'Syntax-Highlighting',
-    'lineno': 'Line-Numbers',
-    'reset': 'Reset Slide',
-    'format': 'Format Source Code',
-    'kill': 'Kill Program',
-    'run': 'Run',
-    'compile': 'Compile and Run',
-    'more': 'Options',
-    'toc': 'Table of Contents',
-    'prev': 'Previous',
-    'next': 'Next',
-    'waiting': 'Waiting for remote server...',
-    'errcomm': 'Error communicating with remote server.',
-}).
+// Locale of the messages of the tour, whose catalog is
+// static/messages/<locale>.json.
+value('locale', 'zh-CN').
 
 // Config for codemirror plugin
 value('ui.config', {